@@ -0,0 +1,204 @@
+package budget
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule kinds reported in violations.
+const (
+	RuleTotal     = "total"
+	RuleDirectory = "directory"
+	RuleFile      = "file"
+	// RuleUnknownDirectory is a directory rule whose path holds no counted
+	// files, such as a typo or a renamed directory. It fails the check so
+	// the budget is not silently off.
+	RuleUnknownDirectory = "unknown_directory"
+)
+
+// Tokens is a token count that also parses human-friendly suffixes
+// such as "20k" or "2M".
+type Tokens int
+
+// ParseTokens parses "20000", "20k", "1.5M", or "2m" into a token count.
+func ParseTokens(raw string) (Tokens, error) {
+	s := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(raw, "_", "")))
+	s = strings.ReplaceAll(s, ",", "")
+	if s == "" {
+		return 0, fmt.Errorf("empty token count")
+	}
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier = 1_000
+		s = strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1_000_000
+		s = strings.TrimSuffix(s, "m")
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid token count: %q", raw)
+	}
+	return Tokens(value * multiplier), nil
+}
+
+func (t *Tokens) UnmarshalText(text []byte) error {
+	parsed, err := ParseTokens(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+func (t Tokens) String() string {
+	return strconv.Itoa(int(t))
+}
+
+// Set and Type let Tokens be used as a pflag value.
+func (t *Tokens) Set(raw string) error {
+	return t.UnmarshalText([]byte(raw))
+}
+
+func (t *Tokens) Type() string {
+	return "tokens"
+}
+
+// Rules declares token limits. Zero values are unlimited.
+type Rules struct {
+	Total       Tokens            `yaml:"total,omitempty" json:"total,omitempty"`
+	File        Tokens            `yaml:"file,omitempty" json:"file,omitempty"`
+	Directories map[string]Tokens `yaml:"directories,omitempty" json:"directories,omitempty"`
+}
+
+// Empty reports whether no limits are declared.
+func (r Rules) Empty() bool {
+	return r.Total <= 0 && r.File <= 0 && len(r.Directories) == 0
+}
+
+// Merge overlays non-zero limits from other onto r.
+func (r Rules) Merge(other Rules) Rules {
+	out := Rules{Total: r.Total, File: r.File}
+	if other.Total > 0 {
+		out.Total = other.Total
+	}
+	if other.File > 0 {
+		out.File = other.File
+	}
+	if len(r.Directories)+len(other.Directories) > 0 {
+		out.Directories = make(map[string]Tokens, len(r.Directories)+len(other.Directories))
+		for path, limit := range r.Directories {
+			out.Directories[path] = limit
+		}
+		for path, limit := range other.Directories {
+			out.Directories[path] = limit
+		}
+	}
+	return out
+}
+
+// LoadRules reads budget rules from a YAML (or JSON) file.
+func LoadRules(path string) (Rules, error) {
+	var rules Rules
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("read budget file: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, &rules); err != nil {
+		return rules, fmt.Errorf("parse budget file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseDirectoryRule parses a "path=limit" flag value.
+func ParseDirectoryRule(raw string) (string, Tokens, error) {
+	path, limit, ok := strings.Cut(raw, "=")
+	if !ok || strings.TrimSpace(path) == "" {
+		return "", 0, fmt.Errorf("invalid directory budget %q (use: path=limit)", raw)
+	}
+	tokens, err := ParseTokens(limit)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid directory budget %q: %w", raw, err)
+	}
+	return strings.TrimSpace(path), tokens, nil
+}
+
+// Violation is a single exceeded limit.
+type Violation struct {
+	Rule   string `json:"rule"`
+	Path   string `json:"path,omitempty"`
+	Limit  int    `json:"limit"`
+	Actual int    `json:"actual"`
+}
+
+// Check evaluates rules against a count result. Violations are ordered by
+// rule kind (total, directory, file) and then by path.
+func Check(result *count.Result, rules Rules) []Violation {
+	if result == nil {
+		return nil
+	}
+
+	violations := make([]Violation, 0)
+	if rules.Total > 0 && result.TotalTokens > int(rules.Total) {
+		violations = append(violations, Violation{Rule: RuleTotal, Limit: int(rules.Total), Actual: result.TotalTokens})
+	}
+
+	dirs := make([]Violation, 0)
+	for path, limit := range rules.Directories {
+		if limit <= 0 {
+			continue
+		}
+		key := directoryKey(path)
+		actual, ok := result.DirectoryTokens[key]
+		if !ok {
+			dirs = append(dirs, Violation{Rule: RuleUnknownDirectory, Path: displayDirectory(key), Limit: int(limit)})
+			continue
+		}
+		if actual > int(limit) {
+			dirs = append(dirs, Violation{Rule: RuleDirectory, Path: displayDirectory(key), Limit: int(limit), Actual: actual})
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].Path < dirs[j].Path })
+	violations = append(violations, dirs...)
+
+	if rules.File > 0 {
+		files := make([]Violation, 0)
+		for _, file := range result.Files {
+			if file.Tokens > int(rules.File) {
+				files = append(files, Violation{Rule: RuleFile, Path: file.Path, Limit: int(rules.File), Actual: file.Tokens})
+			}
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		violations = append(violations, files...)
+	}
+
+	return violations
+}
+
+// directoryKey maps a user-supplied directory to a DirectoryTokens key.
+func directoryKey(path string) string {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return "."
+	}
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+func displayDirectory(key string) string {
+	if key == "." {
+		return "./"
+	}
+	return filepath.ToSlash(key) + "/"
+}
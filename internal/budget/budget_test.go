@@ -0,0 +1,88 @@
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+func TestParseTokens(t *testing.T) {
+	cases := map[string]Tokens{
+		"20000": 20000,
+		"20k":   20000,
+		"1.5M":  1500000,
+		"2m":    2000000,
+		"300K":  300000,
+	}
+	for raw, want := range cases {
+		got, err := ParseTokens(raw)
+		if err != nil {
+			t.Fatalf("ParseTokens(%q): %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("ParseTokens(%q) = %d, want %d", raw, got, want)
+		}
+	}
+	if _, err := ParseTokens("lots"); err == nil {
+		t.Fatalf("expected error for invalid token count")
+	}
+}
+
+func TestCheck_ReportsEachViolation(t *testing.T) {
+	result := &count.Result{
+		TotalTokens: 2_500_000,
+		DirectoryTokens: map[string]int{
+			".":                         2_500_000,
+			"src":                       2_000_000,
+			filepath.Join("src", "api"): 400_000,
+		},
+		Files: []count.FileStat{
+			{Path: "src/api/schema.ts", Tokens: 25_000},
+			{Path: "src/main.go", Tokens: 900},
+		},
+	}
+
+	rules := Rules{
+		Total: 2_000_000,
+		File:  20_000,
+		Directories: map[string]Tokens{
+			"src/api/": 300_000,
+			"./src":    3_000_000,
+			"srcc":     3_000,
+		},
+	}
+
+	got := Check(result, rules)
+	want := []Violation{
+		{Rule: RuleTotal, Limit: 2_000_000, Actual: 2_500_000},
+		{Rule: RuleDirectory, Path: "src/api/", Limit: 300_000, Actual: 400_000},
+		{Rule: RuleUnknownDirectory, Path: "srcc/", Limit: 3_000},
+		{Rule: RuleFile, Path: "src/api/schema.ts", Limit: 20_000, Actual: 25_000},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d violations, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("violation %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestLoadRules_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budgets.yaml")
+	content := "total: 2M\nfile: 20k\ndirectories:\n  src/services/: 300k\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.Total != 2_000_000 || rules.File != 20_000 || rules.Directories["src/services/"] != 300_000 {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+}
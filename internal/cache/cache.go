@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Cache is a content-addressed on-disk store of token counts.
+//
+// Entries are keyed by the SHA-256 of file content and the tokenizer
+// identity, so a changed file or tokenizer simply misses. Each entry is
+// written to a temp file and renamed into place, which keeps concurrent
+// tokcount processes from observing partial writes.
+type Cache struct {
+	dir string
+}
+
+// Stats summarizes cache contents on disk.
+type Stats struct {
+	Dir        string `json:"dir"`
+	Entries    int    `json:"entries"`
+	Bytes      int64  `json:"bytes"`
+	Tokenizers int    `json:"tokenizers"`
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/tokcount (or the OS user cache dir).
+func DefaultDir() (string, error) {
+	if xdg := strings.TrimSpace(os.Getenv("XDG_CACHE_HOME")); xdg != "" {
+		return filepath.Join(xdg, "tokcount"), nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve cache dir: %w", err)
+	}
+	return filepath.Join(base, "tokcount"), nil
+}
+
+// Open returns a cache rooted at dir, creating it if needed.
+func Open(dir string) (*Cache, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("cache dir is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+func (c *Cache) Dir() string {
+	if c == nil {
+		return ""
+	}
+	return c.dir
+}
+
+// TokenizerKey identifies a tokenizer configuration for cache lookups.
+func TokenizerKey(name string, detail string) string {
+	sum := sha256.Sum256([]byte(name + "\x00" + detail))
+	return hex.EncodeToString(sum[:8])
+}
+
+// HashContent returns the content address used for lookups.
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached token count for content under a tokenizer key.
+func (c *Cache) Get(tokenizerKey string, contentHash string) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	raw, err := os.ReadFile(c.entryPath(tokenizerKey, contentHash))
+	if err != nil {
+		return 0, false
+	}
+	tokens, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil || tokens < 0 {
+		return 0, false
+	}
+	return tokens, true
+}
+
+// Put stores a token count. Failures are returned but safe to ignore.
+func (c *Cache) Put(tokenizerKey string, contentHash string, tokens int) error {
+	if c == nil {
+		return nil
+	}
+	path := c.entryPath(tokenizerKey, contentHash)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.WriteString(strconv.Itoa(tokens)); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// Stats walks the cache directory and reports its size.
+func (c *Cache) Stats() (Stats, error) {
+	stats := Stats{Dir: c.Dir()}
+	if c == nil {
+		return stats, nil
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return stats, nil
+		}
+		return stats, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			stats.Tokenizers++
+		}
+	}
+
+	err = filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, os.ErrNotExist) {
+				return nil
+			}
+			return walkErr
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+// Clear removes every cache entry.
+func (c *Cache) Clear() error {
+	if c == nil {
+		return nil
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(c.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) entryPath(tokenizerKey string, contentHash string) string {
+	shard := contentHash
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(c.dir, tokenizerKey, shard, contentHash)
+}
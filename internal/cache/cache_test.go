@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCache_PutGetAndClear(t *testing.T) {
+	c, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := TokenizerKey("openai", "cl100k_base (GPT-4)")
+	hash := HashContent([]byte("package main\n"))
+
+	if _, ok := c.Get(key, hash); ok {
+		t.Fatalf("expected miss on empty cache")
+	}
+	if err := c.Put(key, hash, 42); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := c.Get(key, hash); !ok || got != 42 {
+		t.Fatalf("expected hit with 42 tokens, got %d (hit=%v)", got, ok)
+	}
+	if _, ok := c.Get(TokenizerKey("estimate", "estimate (chars / 3.5)"), hash); ok {
+		t.Fatalf("expected miss for a different tokenizer")
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1 || stats.Tokenizers != 1 {
+		t.Fatalf("expected 1 entry under 1 tokenizer, got %+v", stats)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get(key, hash); ok {
+		t.Fatalf("expected miss after clear")
+	}
+}
+
+func TestCache_ConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	key := TokenizerKey("openai", "cl100k_base (GPT-4)")
+	hash := HashContent([]byte("shared content"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Separate handles mimic separate tokcount processes.
+			c, err := Open(dir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := c.Put(key, hash, 7); err != nil {
+				t.Error(err)
+			}
+			if got, ok := c.Get(key, hash); ok && got != 7 {
+				t.Errorf("read torn entry: %d", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,289 @@
+// Package calibrate fits estimate chars/token ratios to a real tokenizer.
+package calibrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+)
+
+const (
+	defaultSamplePerExtension = 50
+	defaultMinFiles           = 3
+	// minErrorTokens keeps tiny files, where rounding dominates, out of the
+	// error statistics.
+	minErrorTokens = 20
+)
+
+// Options controls a calibration run.
+type Options struct {
+	// Files are the candidates, typically count.Result.Files of a scan.
+	Files []count.FileStat
+	// Read returns the content of a candidate by its slash path.
+	Read func(path string) ([]byte, error)
+	// Reference is the tokenizer the ratios are fit to.
+	Reference tokenizer.Tokenizer
+	// Current is the estimator in use today, reported for comparison. It
+	// defaults to the built-in estimate table.
+	Current tokenizer.Tokenizer
+	// SamplePerExtension caps the files tokenized per extension.
+	SamplePerExtension int
+	// MinFiles is the number of sampled files an extension needs before
+	// its ratio is written.
+	MinFiles    int
+	Concurrency int
+}
+
+// Report is the fitted table and how well it predicts the reference.
+type Report struct {
+	Reference      string         `json:"reference"`
+	CandidateFiles int            `json:"candidate_files"`
+	SampledFiles   int            `json:"sampled_files"`
+	CharsPerToken  float64        `json:"chars_per_token"`
+	Extensions     []ExtensionFit `json:"extensions"`
+	// Totals over all sampled files.
+	ActualTokens  int      `json:"actual_tokens"`
+	CurrentTokens int      `json:"current_estimate_tokens"`
+	Current       ErrorFit `json:"current_error"`
+	Calibrated    ErrorFit `json:"calibrated_error"`
+}
+
+// ExtensionFit is the fitted ratio for one extension.
+type ExtensionFit struct {
+	Extension     string   `json:"extension"`
+	Files         int      `json:"files"`
+	Bytes         int64    `json:"bytes"`
+	Tokens        int      `json:"tokens"`
+	CharsPerToken float64  `json:"chars_per_token"`
+	Written       bool     `json:"written"`
+	Current       ErrorFit `json:"current_error"`
+	Calibrated    ErrorFit `json:"calibrated_error"`
+}
+
+// ErrorFit summarizes per-file relative errors (estimate/actual - 1).
+// Calibrated errors are leave-one-out: each file is predicted with a ratio
+// fit to the other sampled files, its extension's or, below MinFiles, all
+// of them, so they bound unseen files too.
+type ErrorFit struct {
+	Files int     `json:"files"`
+	Mean  float64 `json:"mean_abs"`
+	P90   float64 `json:"p90_abs"`
+}
+
+type sample struct {
+	stat    count.FileStat
+	tokens  int
+	current int
+}
+
+// Run samples Files, tokenizes them with Reference, and fits a chars/token
+// ratio per extension.
+func Run(opts Options) (Report, error) {
+	if opts.Reference == nil || opts.Read == nil {
+		return Report{}, fmt.Errorf("calibrate: reference tokenizer and reader are required")
+	}
+	if opts.SamplePerExtension <= 0 {
+		opts.SamplePerExtension = defaultSamplePerExtension
+	}
+	if opts.MinFiles <= 0 {
+		opts.MinFiles = defaultMinFiles
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = runtime.GOMAXPROCS(0)
+	}
+	if opts.Current == nil {
+		current, err := tokenizer.NewCalibratedEstimate(0, nil)
+		if err != nil {
+			return Report{}, err
+		}
+		opts.Current = current
+	}
+
+	samples := pickSamples(opts.Files, opts.SamplePerExtension)
+	if err := tokenizeSamples(samples, opts); err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Reference:      opts.Reference.Description(),
+		CandidateFiles: len(opts.Files),
+		SampledFiles:   len(samples),
+	}
+
+	groups := make(map[string][]sample)
+	var totalBytes int64
+	for _, s := range samples {
+		totalBytes += s.stat.Bytes
+		report.ActualTokens += s.tokens
+		report.CurrentTokens += s.current
+		if s.stat.Extension != "" {
+			groups[s.stat.Extension] = append(groups[s.stat.Extension], s)
+		}
+	}
+	report.CharsPerToken = ratio(totalBytes, report.ActualTokens, tokenizer.DefaultCharsPerToken)
+
+	var currentErrs, calibratedErrs []float64
+	for ext, group := range groups {
+		fit := ExtensionFit{Extension: ext, Files: len(group)}
+		for _, s := range group {
+			fit.Bytes += s.stat.Bytes
+			fit.Tokens += s.tokens
+		}
+		fit.CharsPerToken = round2(ratio(fit.Bytes, fit.Tokens, report.CharsPerToken))
+		fit.Written = fit.Files >= opts.MinFiles && fit.Tokens > 0
+
+		var cur, cal []float64
+		for _, s := range group {
+			if s.tokens < minErrorTokens {
+				continue
+			}
+			cur = append(cur, relError(s.current, s.tokens))
+			// Leave s out of the fallback too: an extension below MinFiles
+			// gets the overall ratio, which would otherwise include it.
+			looRatio := ratio(totalBytes-s.stat.Bytes, report.ActualTokens-s.tokens, tokenizer.DefaultCharsPerToken)
+			if fit.Written {
+				looRatio = ratio(fit.Bytes-s.stat.Bytes, fit.Tokens-s.tokens, looRatio)
+			}
+			cal = append(cal, relError(int(math.Round(float64(s.stat.Bytes)/looRatio)), s.tokens))
+		}
+		fit.Current, fit.Calibrated = summarize(cur), summarize(cal)
+		currentErrs = append(currentErrs, cur...)
+		calibratedErrs = append(calibratedErrs, cal...)
+		report.Extensions = append(report.Extensions, fit)
+	}
+	report.CharsPerToken = round2(report.CharsPerToken)
+	report.Current, report.Calibrated = summarize(currentErrs), summarize(calibratedErrs)
+
+	sort.Slice(report.Extensions, func(i, j int) bool {
+		a, b := report.Extensions[i], report.Extensions[j]
+		if a.Tokens == b.Tokens {
+			return a.Extension < b.Extension
+		}
+		return a.Tokens > b.Tokens
+	})
+	return report, nil
+}
+
+// RatioFile returns the table to hand to the estimate tokenizer.
+func (r Report) RatioFile() tokenizer.RatioFile {
+	file := tokenizer.RatioFile{
+		Tokenizer:     r.Reference,
+		CharsPerToken: r.CharsPerToken,
+		Ratios:        make(map[string]float64),
+	}
+	for _, fit := range r.Extensions {
+		if fit.Written {
+			file.Ratios[fit.Extension] = fit.CharsPerToken
+		}
+	}
+	return file
+}
+
+// pickSamples takes up to limit files per extension. Files are ordered by
+// a hash of their path, so the sample is stable across runs but spread
+// across the tree.
+func pickSamples(files []count.FileStat, limit int) []sample {
+	byExt := make(map[string][]count.FileStat)
+	for _, file := range files {
+		if file.Bytes == 0 {
+			continue
+		}
+		byExt[file.Extension] = append(byExt[file.Extension], file)
+	}
+
+	exts := make([]string, 0, len(byExt))
+	for ext := range byExt {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	var samples []sample
+	for _, ext := range exts {
+		group := byExt[ext]
+		sort.Slice(group, func(i, j int) bool { return pathHash(group[i].Path) < pathHash(group[j].Path) })
+		if len(group) > limit {
+			group = group[:limit]
+		}
+		for _, file := range group {
+			samples = append(samples, sample{stat: file})
+		}
+	}
+	return samples
+}
+
+func tokenizeSamples(samples []sample, opts Options) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	jobs := make(chan int)
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				data, err := opts.Read(samples[i].stat.Path)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("read %s: %w", samples[i].stat.Path, err)
+					}
+					mu.Unlock()
+					continue
+				}
+				text := string(data)
+				samples[i].tokens = tokenizer.CountFile(opts.Reference, samples[i].stat.Path, text)
+				samples[i].current = tokenizer.CountFile(opts.Current, samples[i].stat.Path, text)
+			}
+		}()
+	}
+	for i := range samples {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+func summarize(errs []float64) ErrorFit {
+	if len(errs) == 0 {
+		return ErrorFit{}
+	}
+	abs := make([]float64, len(errs))
+	sum := 0.0
+	for i, e := range errs {
+		abs[i] = math.Abs(e)
+		sum += abs[i]
+	}
+	sort.Float64s(abs)
+	p90 := abs[int(math.Ceil(0.9*float64(len(abs))))-1]
+	return ErrorFit{Files: len(abs), Mean: sum / float64(len(abs)), P90: p90}
+}
+
+func ratio(bytes int64, tokens int, fallback float64) float64 {
+	if bytes <= 0 || tokens <= 0 {
+		return fallback
+	}
+	return float64(bytes) / float64(tokens)
+}
+
+func relError(estimate int, actual int) float64 {
+	return float64(estimate)/float64(actual) - 1
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func pathHash(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:8])
+}
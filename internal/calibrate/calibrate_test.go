@@ -0,0 +1,117 @@
+package calibrate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+// quarterTokenizer counts one token per four bytes.
+type quarterTokenizer struct{}
+
+func (quarterTokenizer) Count(text string) int { return len(text) / 4 }
+func (quarterTokenizer) Name() string          { return "quarter" }
+func (quarterTokenizer) Description() string   { return "quarter (test)" }
+
+func TestRun_FitsRatioPerExtension(t *testing.T) {
+	contents := make(map[string]string)
+	var files []count.FileStat
+	add := func(path string, ext string, size int) {
+		contents[path] = strings.Repeat("x", size)
+		files = append(files, count.FileStat{Path: path, Bytes: int64(size), Extension: ext})
+	}
+	for i := 0; i < 5; i++ {
+		add(fmt.Sprintf("pkg/file%d.go", i), ".go", 400+i*40)
+	}
+	add("docs/readme.md", ".md", 800)
+
+	report, err := Run(Options{
+		Files:     files,
+		Read:      func(path string) ([]byte, error) { return []byte(contents[path]), nil },
+		Reference: quarterTokenizer{},
+		MinFiles:  3,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.SampledFiles != 6 || report.CharsPerToken != 4 {
+		t.Fatalf("expected 6 sampled files at 4 chars/token, got %d at %g", report.SampledFiles, report.CharsPerToken)
+	}
+	if report.Calibrated.Files != 6 || report.Calibrated.P90 != 0 {
+		t.Fatalf("expected exact leave-one-out fit, got %+v", report.Calibrated)
+	}
+
+	ratios := report.RatioFile()
+	if ratios.Tokenizer != "quarter (test)" || ratios.CharsPerToken != 4 {
+		t.Fatalf("unexpected ratio file header: %+v", ratios)
+	}
+	if got, ok := ratios.Ratios[".go"]; !ok || got != 4 {
+		t.Fatalf("expected .go ratio 4, got %v", ratios.Ratios)
+	}
+	if _, ok := ratios.Ratios[".md"]; ok {
+		t.Fatalf("expected .md to fall back with a single sampled file, got %v", ratios.Ratios)
+	}
+}
+
+func TestRun_SampleIsStableAndCapped(t *testing.T) {
+	var files []count.FileStat
+	for i := 0; i < 20; i++ {
+		files = append(files, count.FileStat{Path: fmt.Sprintf("f%02d.txt", i), Bytes: 100, Extension: ".txt"})
+	}
+	reversed := make([]count.FileStat, len(files))
+	for i, file := range files {
+		reversed[len(files)-1-i] = file
+	}
+
+	first := pickSamples(files, 5)
+	second := pickSamples(reversed, 5)
+	if len(first) != 5 {
+		t.Fatalf("expected 5 samples, got %d", len(first))
+	}
+	for i := range first {
+		if first[i].stat.Path != second[i].stat.Path {
+			t.Fatalf("expected sample independent of input order, got %s and %s", first[i].stat.Path, second[i].stat.Path)
+		}
+	}
+}
+
+// mixedTokenizer counts one token per four "x" bytes and per two others.
+type mixedTokenizer struct{}
+
+func (mixedTokenizer) Count(text string) int {
+	x := strings.Count(text, "x")
+	return x/4 + (len(text)-x)/2
+}
+func (mixedTokenizer) Name() string        { return "mixed" }
+func (mixedTokenizer) Description() string { return "mixed (test)" }
+
+func TestRun_FallbackErrorLeavesTheFileOut(t *testing.T) {
+	contents := make(map[string]string)
+	var files []count.FileStat
+	for i := 0; i < 4; i++ {
+		path := fmt.Sprintf("pkg/file%d.go", i)
+		contents[path] = strings.Repeat("x", 400)
+		files = append(files, count.FileStat{Path: path, Bytes: 400, Extension: ".go"})
+	}
+	contents["tool.py"] = strings.Repeat("y", 400)
+	files = append(files, count.FileStat{Path: "tool.py", Bytes: 400, Extension: ".py"})
+
+	report, err := Run(Options{
+		Files:     files,
+		Read:      func(path string) ([]byte, error) { return []byte(contents[path]), nil },
+		Reference: mixedTokenizer{},
+		MinFiles:  3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fit := range report.Extensions {
+		// Predicted at the .go files' 4 chars/token, the .py file's 200
+		// tokens come out as 100.
+		if fit.Extension == ".py" && (fit.Written || fit.Calibrated.Mean != 0.5) {
+			t.Fatalf("expected the lone .py file to fall back with a 50%% error, got %+v", fit)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"github.com/Napageneral/tokcount/internal/budget"
+	"github.com/spf13/pflag"
+)
+
+// exitCodeBudgetExceeded is returned when any declared budget is exceeded.
+const exitCodeBudgetExceeded = 3
+
+// budgetFlags declare token budgets on the command line.
+type budgetFlags struct {
+	total       budget.Tokens
+	file        budget.Tokens
+	directories []string
+	rulesFile   string
+}
+
+func (f *budgetFlags) register(flags *pflag.FlagSet) {
+	flags.Var(&f.total, "max-total", "Fail when total tokens exceed this budget (e.g. 2M)")
+	flags.Var(&f.file, "max-file", "Fail when any single file exceeds this budget (e.g. 20k)")
+	flags.StringArrayVar(&f.directories, "max-dir", nil, "Fail when a directory exceeds its budget: path=limit (repeatable)")
+	flags.StringVar(&f.rulesFile, "budget-file", "", "YAML/JSON file declaring total, file, and directories budgets")
+}
+
+// rules layers the budget file (if any) and then flag overrides on top of
+// base, which holds budgets from the project config.
+func (f *budgetFlags) rules(base budget.Rules) (budget.Rules, error) {
+	rules := base
+	if f.rulesFile != "" {
+		loaded, err := budget.LoadRules(f.rulesFile)
+		if err != nil {
+			return rules, err
+		}
+		rules = rules.Merge(loaded)
+	}
+
+	overrides := budget.Rules{Total: f.total, File: f.file}
+	if len(f.directories) > 0 {
+		overrides.Directories = make(map[string]budget.Tokens, len(f.directories))
+		for _, raw := range f.directories {
+			path, limit, err := budget.ParseDirectoryRule(raw)
+			if err != nil {
+				return rules, err
+			}
+			overrides.Directories[path] = limit
+		}
+	}
+	return rules.Merge(overrides), nil
+}
+
+// exitError carries a process exit code through cobra's error return.
+type exitError struct {
+	code int
+	msg  string
+}
+
+func (e *exitError) Error() string {
+	return e.msg
+}
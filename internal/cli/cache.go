@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Napageneral/tokcount/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect or clear the token count cache",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Show cache location and size",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := openDefaultCache()
+			if err != nil {
+				return err
+			}
+			stats, err := c.Stats()
+			if err != nil {
+				return fmt.Errorf("read cache stats: %w", err)
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Cache dir: %s\n", stats.Dir)
+			fmt.Fprintf(out, "Entries: %d\n", stats.Entries)
+			fmt.Fprintf(out, "Tokenizers: %d\n", stats.Tokenizers)
+			fmt.Fprintf(out, "Size: %d bytes\n", stats.Bytes)
+			return nil
+		},
+		SilenceUsage: true,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached token counts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := openDefaultCache()
+			if err != nil {
+				return err
+			}
+			if err := c.Clear(); err != nil {
+				return fmt.Errorf("clear cache: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Cleared %s\n", c.Dir())
+			return nil
+		},
+		SilenceUsage: true,
+	})
+
+	return cmd
+}
+
+func openDefaultCache() (*cache.Cache, error) {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Open(dir)
+}
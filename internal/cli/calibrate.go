@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/calibrate"
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/output"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+	"github.com/spf13/cobra"
+)
+
+// defaultRatiosFile is where calibrate writes its table, in the scanned
+// root, when --out is not given.
+const defaultRatiosFile = ".tokcount-ratios.yaml"
+
+func newCalibrateCmd() *cobra.Command {
+	var (
+		scan         scanFlags
+		sources      sourceFlags
+		outputFormat string
+		outPath      string
+		sample       int
+		minFiles     int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "calibrate [path]",
+		Short: "Fit estimate chars/token ratios to a real tokenizer",
+		Long: "calibrate samples files from the repository, counts them with a real tokenizer, and fits a chars/token ratio\n" +
+			"per extension. The table is written to a file the estimate tokenizer loads, so later scans stay cheap but match\n" +
+			"the reference tokenizer on this codebase. Error bounds are reported per extension.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := "."
+			if len(args) == 1 {
+				target = args[0]
+			}
+			rootPath, err := filepath.Abs(target)
+			if err != nil {
+				return fmt.Errorf("resolve repository path: %w", err)
+			}
+
+			cfg, err := scan.loadConfig(rootPath)
+			if err != nil {
+				return err
+			}
+			scan.applyConfig(cmd.Flags(), cfg)
+
+			source, closeSource, err := sources.open(rootPath)
+			if err != nil {
+				return err
+			}
+			defer closeSource()
+
+			// Listing candidates never tokenizes, so skip opening the cache.
+			scan.noCache = true
+			opts, err := scan.options(rootPath, source)
+			if err != nil {
+				return err
+			}
+			var current tokenizer.Tokenizer
+			if estimate, isEstimate := opts.Tokenizer.(*tokenizer.EstimateTokenizer); isEstimate {
+				current = estimate
+				if cmd.Flags().Changed("tokenizer") {
+					return fmt.Errorf("calibrate needs a real tokenizer: openai | openai-o200k | anthropic | hf:<tokenizer.json> | spm:<tokenizer.model>")
+				}
+				// The configured tokenizer is the estimate being calibrated;
+				// fit it to openai unless told otherwise.
+				opts.Tokenizer, err = tokenizer.New("openai")
+				if err != nil {
+					return err
+				}
+			} else {
+				current, err = tokenizer.NewWithOptions("estimate", tokenizer.Options{
+					RatioFile:      scan.ratiosFile,
+					CharsPerToken:  scan.charsPerToken,
+					EstimateRatios: scan.estimateRatios,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			reference := opts.Tokenizer
+			opts.Tokenizer, opts.Compare = tokenizer.NewEstimate(0), nil
+			listing, err := count.Run(opts)
+			if err != nil {
+				return err
+			}
+
+			report, err := calibrate.Run(calibrate.Options{
+				Files:              listing.Files,
+				Read:               sourceReader(rootPath, source),
+				Reference:          reference,
+				Current:            current,
+				SamplePerExtension: sample,
+				MinFiles:           minFiles,
+				Concurrency:        scan.jobs,
+			})
+			if err != nil {
+				return err
+			}
+
+			// An explicit --out is a path like pack's, relative to the working
+			// directory; the default file goes in the scanned root.
+			written := ""
+			if outPath != "-" {
+				written = outPath
+				if written == "" {
+					written = filepath.Join(rootPath, defaultRatiosFile)
+				}
+				payload, err := report.RatioFile().Marshal()
+				if err != nil {
+					return err
+				}
+				header := fmt.Sprintf("# Generated by tokcount calibrate from %d sampled files.\n", report.SampledFiles)
+				if err := os.WriteFile(written, append([]byte(header), payload...), 0o644); err != nil {
+					return fmt.Errorf("write ratio file: %w", err)
+				}
+			}
+
+			switch strings.ToLower(strings.TrimSpace(outputFormat)) {
+			case "", "summary":
+				fmt.Fprint(cmd.OutOrStdout(), output.RenderCalibration(report, written))
+			case "json":
+				payload, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(payload))
+			default:
+				return fmt.Errorf("unsupported output format: %s (use: summary or json)", outputFormat)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "output", "summary", "Output format: summary | json")
+	cmd.Flags().StringVar(&outPath, "out", "", "Ratio file to write (default: "+defaultRatiosFile+" in the scanned root; - to skip writing)")
+	cmd.Flags().IntVar(&sample, "sample", 50, "Maximum files to tokenize per extension")
+	cmd.Flags().IntVar(&minFiles, "min-files", 3, "Sampled files an extension needs before its ratio is written")
+	scan.register(cmd.Flags())
+	sources.register(cmd)
+
+	return cmd
+}
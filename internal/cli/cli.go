@@ -1,31 +1,37 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/Napageneral/tokcount/internal/budget"
 	"github.com/Napageneral/tokcount/internal/count"
-	"github.com/Napageneral/tokcount/internal/ignore"
 	"github.com/Napageneral/tokcount/internal/output"
-	"github.com/Napageneral/tokcount/internal/tokenizer"
 	"github.com/spf13/cobra"
 )
 
 // NewRootCmd constructs the tokcount CLI command.
 func NewRootCmd() *cobra.Command {
 	var (
-		outputFormat  string
-		tokenizerName string
-		ignoreFile    string
-		showTree      bool
+		scan         scanFlags
+		budgets      budgetFlags
+		prices       pricingFlags
+		outputFormat string
+		showTree     bool
+		by           string
+		granularity  string
+		view         string
+		skeletonOut  string
+		sources      sourceFlags
 	)
 
 	cmd := &cobra.Command{
 		Use:   "tokcount [path]",
 		Short: "Count tokens in a repository",
-		Long:  "tokcount scans a repository, counts tokens, and prints an Intent Layer pricing estimate.",
+		Long:  "tokcount scans a repository, counts tokens, and prints the breakdown with the pricing section chosen by --pricing.",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			target := "."
@@ -38,33 +44,92 @@ func NewRootCmd() *cobra.Command {
 				return fmt.Errorf("resolve repository path: %w", err)
 			}
 
-			selectedTokenizer, err := tokenizer.New(tokenizerName)
+			cfg, err := scan.loadConfig(rootPath)
 			if err != nil {
 				return err
 			}
+			scan.applyConfig(cmd.Flags(), cfg)
+			if !cmd.Flags().Changed("output") && cfg.Output != "" {
+				outputFormat = cfg.Output
+			}
+			if !cmd.Flags().Changed("tree") && cfg.Tree != nil {
+				showTree = *cfg.Tree
+			}
+			if !cmd.Flags().Changed("by") && cfg.By != "" {
+				by = cfg.By
+			}
+			if !cmd.Flags().Changed("view") && cfg.View != "" {
+				view = cfg.View
+			}
+			prices.applyConfig(cmd.Flags(), cfg)
+			renderOpts, err := prices.options()
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(strings.TrimSpace(by)) {
+			case "", output.ByDirectory:
+				renderOpts.By = output.ByDirectory
+			case output.ByLanguage:
+				renderOpts.By = output.ByLanguage
+			default:
+				return fmt.Errorf("unsupported breakdown: %s (use: directory or language)", by)
+			}
+			symbolLeaves := false
+			switch strings.ToLower(strings.TrimSpace(granularity)) {
+			case "", granularityFile:
+			case granularitySymbol:
+				symbolLeaves = true
+			default:
+				return fmt.Errorf("unsupported granularity: %s (use: file or symbol)", granularity)
+			}
+			switch strings.ToLower(strings.TrimSpace(view)) {
+			case "", viewFull:
+				scan.skeleton = skeletonOut != ""
+			case viewSkeleton:
+				scan.skeleton = true
+			default:
+				return fmt.Errorf("unsupported view: %s (use: full or skeleton)", view)
+			}
 
-			ignoreSpec, err := ignore.LoadSpec(rootPath, ignoreFile)
+			rules, err := budgets.rules(cfg.Budgets)
 			if err != nil {
 				return err
 			}
 
-			result, err := count.Run(count.Options{
-				Root:       rootPath,
-				Tokenizer:  selectedTokenizer,
-				IgnoreSpec: ignoreSpec,
-			})
+			source, closeSource, err := sources.open(rootPath)
 			if err != nil {
 				return err
 			}
+			defer closeSource()
+			scanOpts, err := scan.options(rootPath, source)
+			if err != nil {
+				return err
+			}
+			result, err := count.Run(scanOpts)
+			if err != nil {
+				return err
+			}
+
+			if skeletonOut != "" {
+				if err := writeSkeleton(skeletonOut, rootPath, source, result); err != nil {
+					return err
+				}
+			}
+			if symbolLeaves {
+				if renderOpts.Symbols, err = symbolIndex(scanOpts, result); err != nil {
+					return err
+				}
+				showTree = true
+			}
 
 			switch strings.ToLower(strings.TrimSpace(outputFormat)) {
 			case "", "summary":
-				fmt.Fprintln(cmd.OutOrStdout(), output.RenderSummary(result))
+				fmt.Fprintln(cmd.OutOrStdout(), output.RenderSummary(result, renderOpts))
 				if showTree {
-					fmt.Fprintln(cmd.OutOrStdout(), output.RenderTree(result))
+					fmt.Fprintln(cmd.OutOrStdout(), output.RenderTree(result, renderOpts))
 				}
 			case "json":
-				payload, err := output.RenderJSON(result)
+				payload, err := output.RenderJSON(result, renderOpts)
 				if err != nil {
 					return err
 				}
@@ -73,15 +138,35 @@ func NewRootCmd() *cobra.Command {
 				return fmt.Errorf("unsupported output format: %s (use: summary or json)", outputFormat)
 			}
 
+			if violations := budget.Check(result, rules); len(violations) > 0 {
+				fmt.Fprint(cmd.ErrOrStderr(), output.RenderBudgetViolations(violations))
+				return &exitError{code: exitCodeBudgetExceeded, msg: "token budget exceeded"}
+			}
+
 			return nil
 		},
-		SilenceUsage: true,
+		SilenceUsage:  true,
+		SilenceErrors: true,
 	}
 
 	cmd.Flags().StringVar(&outputFormat, "output", "summary", "Output format: summary | json")
-	cmd.Flags().StringVar(&tokenizerName, "tokenizer", "estimate", "Tokenizer: estimate | openai | anthropic")
-	cmd.Flags().StringVar(&ignoreFile, "ignore", "", "Custom ignore file path (gitignore syntax)")
 	cmd.Flags().BoolVar(&showTree, "tree", false, "Show full directory tree breakdown (summary output only)")
+	cmd.Flags().StringVar(&granularity, "granularity", granularityFile, "Tree leaves: file | symbol (lists declarations under each file; implies --tree)")
+	cmd.Flags().StringVar(&view, "view", viewFull, "Also count a transformed view: full | skeleton (signatures, types and doc comments, bodies elided)")
+	cmd.Flags().StringVar(&skeletonOut, "skeleton-out", "", "Write the skeleton of every counted file to this file (implies --view skeleton)")
+	cmd.Flags().StringVar(&by, "by", output.ByDirectory, "Break down tokens by: directory | language")
+	scan.register(cmd.Flags())
+	budgets.register(cmd.Flags())
+	prices.register(cmd.Flags())
+	sources.register(cmd)
+
+	cmd.AddCommand(newCacheCmd())
+	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newExplainCmd())
+	cmd.AddCommand(newCalibrateCmd())
+	cmd.AddCommand(newPackCmd())
+	cmd.AddCommand(newSymbolsCmd())
 
 	return cmd
 }
@@ -89,6 +174,10 @@ func NewRootCmd() *cobra.Command {
 // Execute runs the tokcount command and exits on failure.
 func Execute() {
 	if err := NewRootCmd().Execute(); err != nil {
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
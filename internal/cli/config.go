@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Napageneral/tokcount/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect project configuration",
+	}
+
+	var configPath string
+	show := &cobra.Command{
+		Use:   "show [path]",
+		Short: "Print the effective merged configuration for a path",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := "."
+			if len(args) == 1 {
+				target = args[0]
+			}
+			rootPath, err := filepath.Abs(target)
+			if err != nil {
+				return fmt.Errorf("resolve path: %w", err)
+			}
+
+			scan := scanFlags{configPath: configPath}
+			cfg, err := scan.loadConfig(rootPath)
+			if err != nil {
+				return err
+			}
+			cfg = config.WithDefaults(cfg)
+
+			out := cmd.OutOrStdout()
+			if len(cfg.Sources) == 0 {
+				fmt.Fprintln(out, "# no config files found; showing defaults")
+			}
+			for _, source := range cfg.Sources {
+				fmt.Fprintf(out, "# from %s\n", source)
+			}
+			rendered, err := cfg.YAML()
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, rendered)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+	show.Flags().StringVar(&configPath, "config", "", "Config file path (default: discover from path upward)")
+
+	cmd.AddCommand(show)
+	return cmd
+}
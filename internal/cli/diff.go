@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newDiffCmd() *cobra.Command {
+	var (
+		scan         scanFlags
+		outputFormat string
+		repoPath     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <base> <head>",
+		Short: "Show token deltas between two git revisions or two directories",
+		Long: "diff counts <base> and <head> and reports added, removed, and changed files plus directory deltas.\n" +
+			"When both arguments are directories they are scanned directly; otherwise they are resolved as git revisions of --repo.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseArg, headArg := args[0], args[1]
+
+			configDir := repoPath
+			if isDir(baseArg) && isDir(headArg) {
+				configDir = headArg
+			}
+			cfg, err := scan.loadConfig(configDir)
+			if err != nil {
+				return err
+			}
+			scan.applyConfig(cmd.Flags(), cfg)
+
+			var base, head *count.Result
+			if isDir(baseArg) && isDir(headArg) {
+				basePath, err := filepath.Abs(baseArg)
+				if err != nil {
+					return fmt.Errorf("resolve base path: %w", err)
+				}
+				headPath, err := filepath.Abs(headArg)
+				if err != nil {
+					return fmt.Errorf("resolve head path: %w", err)
+				}
+				if base, err = scan.run(basePath, nil); err != nil {
+					return fmt.Errorf("count base: %w", err)
+				}
+				if head, err = scan.run(headPath, nil); err != nil {
+					return fmt.Errorf("count head: %w", err)
+				}
+			} else {
+				rootPath, err := filepath.Abs(repoPath)
+				if err != nil {
+					return fmt.Errorf("resolve repository path: %w", err)
+				}
+				if base, err = scan.runRevision(rootPath, baseArg); err != nil {
+					return fmt.Errorf("count base: %w", err)
+				}
+				if head, err = scan.runRevision(rootPath, headArg); err != nil {
+					return fmt.Errorf("count head: %w", err)
+				}
+			}
+
+			diff := output.ComputeDiff(baseArg, base, headArg, head)
+			switch strings.ToLower(strings.TrimSpace(outputFormat)) {
+			case "", "summary":
+				fmt.Fprintln(cmd.OutOrStdout(), output.RenderDiffSummary(diff))
+			case "json":
+				payload, err := output.RenderDiffJSON(diff)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(payload))
+			case "markdown", "md":
+				fmt.Fprint(cmd.OutOrStdout(), output.RenderDiffMarkdown(diff))
+			default:
+				return fmt.Errorf("unsupported output format: %s (use: summary, json, or markdown)", outputFormat)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "output", "summary", "Output format: summary | json | markdown")
+	cmd.Flags().StringVar(&repoPath, "repo", ".", "Repository used to resolve git revisions")
+	scan.register(cmd.Flags())
+
+	return cmd
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newExplainCmd() *cobra.Command {
+	var (
+		scan         scanFlags
+		outputFormat string
+		rootArg      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "explain <path>...",
+		Short: "Show why paths were counted or ignored",
+		Long: "explain reports the rule that decides each path, like git check-ignore -v: the ignore file, line, and pattern,\n" +
+			"or the size cap or binary heuristic that skipped it.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rootPath, err := filepath.Abs(rootArg)
+			if err != nil {
+				return fmt.Errorf("resolve repository path: %w", err)
+			}
+			cfg, err := scan.loadConfig(rootPath)
+			if err != nil {
+				return err
+			}
+			scan.applyConfig(cmd.Flags(), cfg)
+
+			// Explaining never tokenizes, so skip opening the cache.
+			scan.noCache = true
+			opts, err := scan.options(rootPath, nil)
+			if err != nil {
+				return err
+			}
+
+			explanations := make([]count.Explanation, 0, len(args))
+			for _, arg := range args {
+				exp, err := count.Explain(opts, arg)
+				if err != nil {
+					return err
+				}
+				explanations = append(explanations, exp)
+			}
+
+			switch strings.ToLower(strings.TrimSpace(outputFormat)) {
+			case "", "summary":
+				fmt.Fprint(cmd.OutOrStdout(), output.RenderExplanations(explanations, rootPath))
+			case "json":
+				payload, err := json.MarshalIndent(explanations, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(payload))
+			default:
+				return fmt.Errorf("unsupported output format: %s (use: summary or json)", outputFormat)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "output", "summary", "Output format: summary | json")
+	cmd.Flags().StringVar(&rootArg, "root", ".", "Repository root the paths are scanned from")
+	scan.register(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/tokcount/internal/budget"
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/gitsrc"
+	"github.com/Napageneral/tokcount/internal/output"
+	"github.com/Napageneral/tokcount/internal/pack"
+	"github.com/spf13/cobra"
+)
+
+func newPackCmd() *cobra.Command {
+	var (
+		scan         scanFlags
+		limit        budget.Tokens
+		format       string
+		strategy     string
+		priority     []string
+		outPath      string
+		reportFormat string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pack [path]",
+		Short: "Bundle files into a prompt-ready file under a token budget",
+		Long: "pack selects files with the scan's ignore rules and tokenizer, in priority order, while they fit --budget, and writes\n" +
+			"them as XML-tagged or markdown-fenced sections. The reported count is of the packed output, wrappers included.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit <= 0 {
+				return fmt.Errorf("--budget is required (e.g. 150k)")
+			}
+			target := "."
+			if len(args) == 1 {
+				target = args[0]
+			}
+			rootPath, err := filepath.Abs(target)
+			if err != nil {
+				return fmt.Errorf("resolve repository path: %w", err)
+			}
+
+			cfg, err := scan.loadConfig(rootPath)
+			if err != nil {
+				return err
+			}
+			scan.applyConfig(cmd.Flags(), cfg)
+			opts, err := scan.options(rootPath, nil)
+			if err != nil {
+				return err
+			}
+			opts.Compare = nil
+			listing, err := count.Run(opts)
+			if err != nil {
+				return err
+			}
+
+			packOpts := pack.Options{
+				Files: listing.Files,
+				Read: func(path string) ([]byte, error) {
+					return os.ReadFile(filepath.Join(rootPath, filepath.FromSlash(path)))
+				},
+				Tokenizer: opts.Tokenizer,
+				Budget:    int(limit),
+				Format:    format,
+				Strategy:  strategy,
+				Priority:  priority,
+			}
+			if strings.EqualFold(strings.TrimSpace(strategy), pack.StrategyRecent) {
+				packOpts.ChangedAt = changeTimes(rootPath, listing.Files)
+			}
+			result, err := pack.Run(packOpts)
+			if err != nil {
+				return err
+			}
+
+			if outPath == "" || outPath == "-" {
+				fmt.Fprint(cmd.OutOrStdout(), result.Output)
+			} else if err := os.WriteFile(outPath, []byte(result.Output), 0o644); err != nil {
+				return fmt.Errorf("write pack: %w", err)
+			}
+
+			// The report goes to stderr when the pack itself is on stdout.
+			report := cmd.ErrOrStderr()
+			if outPath != "" && outPath != "-" {
+				report = cmd.OutOrStdout()
+			}
+			switch strings.ToLower(strings.TrimSpace(reportFormat)) {
+			case "", "summary":
+				fmt.Fprint(report, output.RenderPackReport(result, outPath))
+			case "json":
+				payload, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(report, string(payload))
+			default:
+				return fmt.Errorf("unsupported output format: %s (use: summary or json)", reportFormat)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().Var(&limit, "budget", "Most tokens the packed output may hold, wrappers included (e.g. 150k)")
+	cmd.Flags().StringVar(&format, "format", pack.FormatXML, "Section format: xml | markdown (xml content is unescaped, so a file containing </file> ends its section early)")
+	cmd.Flags().StringVar(&strategy, "strategy", pack.StrategySmallest, "Order files compete for the budget in: smallest | recent")
+	cmd.Flags().StringArrayVar(&priority, "priority", nil, "Pack files matching this pattern first (gitignore syntax, repeatable, in order)")
+	cmd.Flags().StringVar(&outPath, "out", "", "Write the pack to this file instead of stdout")
+	cmd.Flags().StringVar(&reportFormat, "output", "summary", "Report format: summary | json")
+	scan.register(cmd.Flags())
+
+	return cmd
+}
+
+// changeTimes reports when files last changed according to git, falling
+// back to the modification time for untracked files and non-git trees.
+func changeTimes(rootPath string, files []count.FileStat) func(path string) time.Time {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = filepath.ToSlash(file.Path)
+	}
+	changed, err := gitsrc.LastChanged(rootPath, paths)
+	if err != nil {
+		changed = nil
+	}
+	return func(path string) time.Time {
+		if t, ok := changed[filepath.ToSlash(path)]; ok {
+			return t
+		}
+		if info, err := os.Stat(filepath.Join(rootPath, filepath.FromSlash(path))); err == nil {
+			return info.ModTime()
+		}
+		return time.Time{}
+	}
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/budget"
+	"github.com/Napageneral/tokcount/internal/config"
+	"github.com/Napageneral/tokcount/internal/output"
+	"github.com/Napageneral/tokcount/internal/pricing"
+	"github.com/spf13/pflag"
+)
+
+// pricingFlags choose the pricing section, the models it prices, and the
+// context windows to fit the counted tokens into.
+type pricingFlags struct {
+	section        string
+	models         []string
+	catalog        string
+	passesPerDay   int
+	contextWindows []string
+
+	usdPerMillion int
+}
+
+func (f *pricingFlags) register(flags *pflag.FlagSet) {
+	flags.StringVar(&f.section, "pricing", "", "Pricing section: none | intent | catalog (default intent, or catalog with --price-model)")
+	flags.StringSliceVar(&f.models, "price-model", nil, "Show the cost of sending the counted tokens to these models (comma-separated or repeatable)")
+	flags.StringVar(&f.catalog, "price-catalog", "", "YAML/JSON model price file layered over the built-in catalog")
+	flags.IntVar(&f.passesPerDay, "passes-per-day", 0, "Also show daily and monthly model costs for this many full passes per day")
+	flags.StringSliceVar(&f.contextWindows, "context-window", nil, "Report what fits a context window: a size such as 200k, or a catalog model name (comma-separated or repeatable)")
+}
+
+// applyConfig fills every pricing setting whose flag was not set explicitly.
+func (f *pricingFlags) applyConfig(flags *pflag.FlagSet, cfg config.Config) {
+	if !flags.Changed("pricing") && cfg.Pricing.Section != "" {
+		f.section = cfg.Pricing.Section
+	}
+	if !flags.Changed("price-model") && len(cfg.Pricing.Models) > 0 {
+		f.models = cfg.Pricing.Models
+	}
+	if !flags.Changed("price-catalog") && cfg.Pricing.Catalog != "" {
+		f.catalog = cfg.Pricing.Catalog
+	}
+	if !flags.Changed("passes-per-day") && cfg.Pricing.PassesPerDay > 0 {
+		f.passesPerDay = cfg.Pricing.PassesPerDay
+	}
+	if !flags.Changed("context-window") && len(cfg.ContextWindows) > 0 {
+		f.contextWindows = cfg.ContextWindows
+	}
+	f.usdPerMillion = cfg.Pricing.USDPerMillion
+}
+
+// options resolves the pricing section and context windows. A nil section
+// leaves pricing out of the output.
+func (f *pricingFlags) options() (output.Options, error) {
+	var opts output.Options
+	if f.passesPerDay < 0 {
+		return opts, fmt.Errorf("--passes-per-day must not be negative")
+	}
+
+	section := strings.ToLower(strings.TrimSpace(f.section))
+	if section == "" {
+		section = output.PricingIntent
+		if len(f.models) > 0 {
+			section = output.PricingCatalog
+		}
+	}
+	if section != output.PricingCatalog && len(f.models) > 0 {
+		return opts, fmt.Errorf("--price-model needs --pricing catalog, not %s", section)
+	}
+
+	catalog, err := f.loadCatalog()
+	if err != nil {
+		return opts, err
+	}
+	switch section {
+	case output.PricingNone:
+	case output.PricingIntent:
+		opts.Pricing = output.IntentPricing{USDPerMillion: f.usdPerMillion}
+	case output.PricingCatalog:
+		names := f.models
+		if len(names) == 0 {
+			names = catalog.Names()
+		}
+		prices := output.CatalogPricing{PassesPerDay: f.passesPerDay}
+		for _, name := range names {
+			model, err := catalog.Lookup(name)
+			if err != nil {
+				return opts, err
+			}
+			prices.Models = append(prices.Models, model)
+		}
+		opts.Pricing = prices
+	default:
+		return opts, fmt.Errorf("unsupported pricing section: %s (use: none, intent, or catalog)", f.section)
+	}
+
+	for _, raw := range f.contextWindows {
+		window, err := parseContextWindow(raw, catalog)
+		if err != nil {
+			return opts, err
+		}
+		opts.ContextWindows = append(opts.ContextWindows, window)
+	}
+	return opts, nil
+}
+
+func (f *pricingFlags) loadCatalog() (pricing.Catalog, error) {
+	catalog := pricing.Builtin()
+	if f.catalog == "" {
+		return catalog, nil
+	}
+	loaded, err := pricing.LoadCatalog(f.catalog)
+	if err != nil {
+		return catalog, err
+	}
+	return catalog.Merge(loaded), nil
+}
+
+// parseContextWindow reads a size such as "200k", or a model name with a
+// context window in the catalog.
+func parseContextWindow(raw string, catalog pricing.Catalog) (output.ContextWindow, error) {
+	name := strings.TrimSpace(raw)
+	if tokens, err := budget.ParseTokens(name); err == nil {
+		if tokens <= 0 {
+			return output.ContextWindow{}, fmt.Errorf("invalid context window %q: must be positive", raw)
+		}
+		return output.ContextWindow{Name: name, Tokens: int(tokens)}, nil
+	}
+	model, err := catalog.Lookup(name)
+	if err != nil {
+		return output.ContextWindow{}, fmt.Errorf("invalid context window: %w", err)
+	}
+	if model.ContextWindow <= 0 {
+		return output.ContextWindow{}, fmt.Errorf("model %s has no context_window in the price catalog", model.Name)
+	}
+	return output.ContextWindow{Name: model.Name, Tokens: model.ContextWindow}, nil
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/cache"
+	"github.com/Napageneral/tokcount/internal/config"
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/gitsrc"
+	"github.com/Napageneral/tokcount/internal/ignore"
+	"github.com/Napageneral/tokcount/internal/symbols"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// scanFlags are the counting flags shared by tokcount and its subcommands.
+type scanFlags struct {
+	tokenizerName   string
+	tokenizerFile   string
+	ignoreFile      string
+	include         []string
+	maxFileSize     config.ByteSize
+	jobs            int
+	noCache         bool
+	configPath      string
+	estimateIgnored bool
+	ratiosFile      string
+
+	// These come from the project config; there are no flags for them.
+	extraIgnore    []string
+	charsPerToken  float64
+	estimateRatios map[string]float64
+
+	// skeleton also counts each file with its bodies elided; commands set
+	// it from --view.
+	skeleton bool
+}
+
+func (f *scanFlags) register(flags *pflag.FlagSet) {
+	flags.StringVar(&f.tokenizerName, "tokenizer", "estimate", "Tokenizer: estimate | openai | openai-o200k | anthropic | hf:<tokenizer.json> | spm:<tokenizer.model>; a comma-separated list compares several in one scan")
+	flags.StringVar(&f.tokenizerFile, "tokenizer-file", "", "Local .tiktoken BPE ranks for the selected tiktoken tokenizer")
+	flags.StringVar(&f.ratiosFile, "ratios-file", "", "Chars/token table written by tokcount calibrate, for the estimate tokenizer")
+	flags.StringVar(&f.ignoreFile, "ignore", "", "Custom ignore file path (gitignore syntax)")
+	flags.StringArrayVar(&f.include, "include", nil, "Only count files matching this pattern (gitignore syntax, repeatable)")
+	flags.Var(&f.maxFileSize, "max-file-size", "Skip files larger than this size (default 10MiB)")
+	flags.IntVar(&f.jobs, "jobs", runtime.GOMAXPROCS(0), "Number of concurrent tokenizing workers")
+	flags.BoolVar(&f.noCache, "no-cache", false, "Disable the on-disk token count cache")
+	flags.BoolVar(&f.estimateIgnored, "estimate-ignored", false, "Estimate tokens hidden in ignored and oversized text files with the estimate tokenizer's chars/token ratios")
+	flags.StringVar(&f.configPath, "config", "", "Config file path (default: discover .tokcount.yaml/.tokcount.toml from the scanned root upward)")
+}
+
+// loadConfig reads --config, or discovers project config from dir upward.
+func (f *scanFlags) loadConfig(dir string) (config.Config, error) {
+	if strings.TrimSpace(f.configPath) != "" {
+		return config.Load(f.configPath)
+	}
+	return config.Discover(dir)
+}
+
+// applyConfig fills every scan setting whose flag was not set explicitly.
+func (f *scanFlags) applyConfig(flags *pflag.FlagSet, cfg config.Config) {
+	if !flags.Changed("tokenizer") && cfg.Tokenizer != "" {
+		f.tokenizerName = cfg.Tokenizer
+	}
+	if !flags.Changed("tokenizer-file") && cfg.TokenizerFile != "" {
+		f.tokenizerFile = cfg.TokenizerFile
+	}
+	if !flags.Changed("ignore") && cfg.IgnoreFile != "" {
+		f.ignoreFile = cfg.IgnoreFile
+	}
+	if !flags.Changed("include") && len(cfg.Include) > 0 {
+		f.include = cfg.Include
+	}
+	if !flags.Changed("max-file-size") && cfg.MaxFileSize > 0 {
+		f.maxFileSize = cfg.MaxFileSize
+	}
+	f.extraIgnore = cfg.Ignore
+	f.charsPerToken = cfg.Estimate.CharsPerToken
+	f.estimateRatios = cfg.Estimate.Ratios
+	if !flags.Changed("ratios-file") && cfg.Estimate.RatiosFile != "" {
+		f.ratiosFile = cfg.Estimate.RatiosFile
+	}
+}
+
+// revisionSource is a source that reads the tree of a git revision; its
+// ignore files are read from that tree too.
+type revisionSource interface {
+	count.Source
+	Revision() string
+	ReadTreeFile(absPath string) ([]byte, error)
+}
+
+// options resolves the tokenizers, ignore spec, and cache for rootPath,
+// reading files from source when it is not nil. The first tokenizer of a
+// --tokenizer list is primary; the rest are compared.
+func (f *scanFlags) options(rootPath string, source count.Source) (count.Options, error) {
+	toks, err := tokenizer.NewList(f.tokenizerName, tokenizer.Options{
+		RankFile:       f.tokenizerFile,
+		RatioFile:      f.ratiosFile,
+		CharsPerToken:  f.charsPerToken,
+		EstimateRatios: f.estimateRatios,
+	})
+	if err != nil {
+		return count.Options{}, err
+	}
+
+	ignoreOpts := ignore.Options{
+		CustomIgnoreFile: f.ignoreFile,
+		ExtraPatterns:    f.extraIgnore,
+		IncludePatterns:  f.include,
+	}
+	var revision string
+	if rev, ok := source.(revisionSource); ok {
+		ignoreOpts.ReadFile = rev.ReadTreeFile
+		revision = rev.Revision()
+	}
+	ignoreSpec, err := ignore.LoadSpecWithOptions(rootPath, ignoreOpts)
+	if err != nil {
+		return count.Options{}, err
+	}
+
+	var tokenCache *cache.Cache
+	if !f.noCache {
+		// A cache that cannot be opened (read-only home, full disk) only
+		// costs speed, so count without it.
+		tokenCache, err = openDefaultCache()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: token count cache disabled: %v\n", err)
+			tokenCache = nil
+		}
+	}
+
+	opts := count.Options{
+		Root:            rootPath,
+		Tokenizer:       toks[0],
+		Compare:         toks[1:],
+		IgnoreSpec:      ignoreSpec,
+		MaxFileBytes:    int64(f.maxFileSize),
+		Concurrency:     f.jobs,
+		Cache:           tokenCache,
+		EstimateIgnored: f.estimateIgnored,
+		Source:          source,
+		Revision:        revision,
+	}
+	if f.estimateIgnored {
+		// Ignored files are estimated with the same ratios an estimate scan
+		// would use, whatever the primary tokenizer is.
+		estimator, ok := toks[0].(*tokenizer.EstimateTokenizer)
+		if !ok {
+			est, err := tokenizer.NewWithOptions("estimate", tokenizer.Options{
+				RatioFile:      f.ratiosFile,
+				CharsPerToken:  f.charsPerToken,
+				EstimateRatios: f.estimateRatios,
+			})
+			if err != nil {
+				return count.Options{}, err
+			}
+			estimator = est.(*tokenizer.EstimateTokenizer)
+		}
+		opts.Estimator = estimator
+	}
+	if f.skeleton {
+		opts.Skeleton = symbols.Skeleton
+	}
+	return opts, nil
+}
+
+// run counts rootPath, optionally reading files from a git source.
+func (f *scanFlags) run(rootPath string, source count.Source) (*count.Result, error) {
+	opts, err := f.options(rootPath, source)
+	if err != nil {
+		return nil, err
+	}
+	return count.Run(opts)
+}
+
+// runRevision counts the tree of a git revision under rootPath.
+func (f *scanFlags) runRevision(rootPath string, revision string) (*count.Result, error) {
+	revSource, err := gitsrc.NewRevision(rootPath, revision)
+	if err != nil {
+		return nil, err
+	}
+	defer revSource.Close()
+	return f.run(rootPath, revSource)
+}
+
+// sourceFlags select where a scan reads files from: the working tree, the
+// git index, or a revision's tree.
+type sourceFlags struct {
+	gitTracked bool
+	revision   string
+}
+
+func (f *sourceFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.gitTracked, "git-tracked", false, "Count only files tracked in the git index")
+	cmd.Flags().StringVar(&f.revision, "rev", "", "Count the tree at a git revision (read from the object store)")
+	cmd.MarkFlagsMutuallyExclusive("git-tracked", "rev")
+}
+
+// open returns the source the flags select, or nil for the working tree,
+// and a func that releases it.
+func (f *sourceFlags) open(rootPath string) (count.Source, func(), error) {
+	switch {
+	case strings.TrimSpace(f.revision) != "":
+		revSource, err := gitsrc.NewRevision(rootPath, f.revision)
+		if err != nil {
+			return nil, nil, err
+		}
+		return revSource, func() { revSource.Close() }, nil
+	case f.gitTracked:
+		trackedSource, err := gitsrc.NewTracked(rootPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return trackedSource, func() { trackedSource.Close() }, nil
+	}
+	return nil, func() {}, nil
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/ignore"
+	"github.com/Napageneral/tokcount/internal/output"
+	"github.com/Napageneral/tokcount/internal/pack"
+	"github.com/Napageneral/tokcount/internal/symbols"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+	"github.com/spf13/cobra"
+)
+
+func newSymbolsCmd() *cobra.Command {
+	var (
+		scan         scanFlags
+		outputFormat string
+		sortOrder    string
+		limit        int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "symbols [path]",
+		Short: "Count tokens per declaration",
+		Long: "symbols counts the tokens of every declaration, doc comment included, with its file:line span. Go files are\n" +
+			"parsed with go/ast; TypeScript, JavaScript, Java, Rust and Python files are outlined by built-in parsers that\n" +
+			"also list methods and nested types. path may be a directory or a single file.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := "."
+			if len(args) == 1 {
+				target = args[0]
+			}
+			targetPath, err := filepath.Abs(target)
+			if err != nil {
+				return fmt.Errorf("resolve repository path: %w", err)
+			}
+			// A single file is counted from the root of its work tree, so
+			// ignore rules and config apply as in a scan of the repository.
+			rootPath, only := targetPath, ""
+			if info, err := os.Stat(targetPath); err == nil && !info.IsDir() {
+				rootPath = filepath.Dir(targetPath)
+				if workTree, ok := ignore.WorkTree(rootPath); ok {
+					rootPath = workTree
+				}
+				if only, err = filepath.Rel(rootPath, targetPath); err != nil {
+					return fmt.Errorf("resolve symbol file: %w", err)
+				}
+			}
+
+			cfg, err := scan.loadConfig(rootPath)
+			if err != nil {
+				return err
+			}
+			scan.applyConfig(cmd.Flags(), cfg)
+
+			// Listing candidates never tokenizes, so skip opening the cache.
+			scan.noCache = true
+			opts, err := scan.options(rootPath, nil)
+			if err != nil {
+				return err
+			}
+			tok := opts.Tokenizer
+			opts.Tokenizer, opts.Compare = tokenizer.NewEstimate(0), nil
+			if only != "" {
+				opts.Source = singleFile{root: rootPath, relPath: filepath.ToSlash(only)}
+			}
+			listing, err := count.Run(opts)
+			if err != nil {
+				return err
+			}
+
+			result, err := symbols.Run(symbols.Options{
+				Files: listing.Files,
+				Read: func(path string) ([]byte, error) {
+					return os.ReadFile(filepath.Join(rootPath, filepath.FromSlash(path)))
+				},
+				Tokenizer: tok,
+				Sort:      sortOrder,
+			})
+			if err != nil {
+				return err
+			}
+
+			switch strings.ToLower(strings.TrimSpace(outputFormat)) {
+			case "", "summary":
+				fmt.Fprint(cmd.OutOrStdout(), output.RenderSymbols(result, limit))
+			case "json":
+				if limit > 0 && limit < len(result.Symbols) {
+					result.Symbols = result.Symbols[:limit]
+				}
+				payload, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(payload))
+			default:
+				return fmt.Errorf("unsupported output format: %s (use: summary or json)", outputFormat)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "output", "summary", "Output format: summary | json")
+	cmd.Flags().StringVar(&sortOrder, "sort", symbols.SortTokens, "Sort symbols by: tokens | name | file | lines")
+	cmd.Flags().IntVar(&limit, "limit", 25, "Most symbols to list (0 for all)")
+	scan.register(cmd.Flags())
+
+	return cmd
+}
+
+// singleFile lists one file under root, so a scan applies the ignore rules
+// to it without walking the rest of the tree.
+type singleFile struct {
+	root    string
+	relPath string
+}
+
+func (s singleFile) Walk(fn func(entry count.SourceEntry) error) error {
+	info, err := os.Stat(filepath.Join(s.root, filepath.FromSlash(s.relPath)))
+	if err != nil {
+		return err
+	}
+	return fn(count.SourceEntry{RelPath: s.relPath, Size: info.Size()})
+}
+
+func (s singleFile) Read(relPath string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.root, filepath.FromSlash(relPath)))
+}
+
+// Tree leaves accepted by --granularity.
+const (
+	granularityFile   = "file"
+	granularitySymbol = "symbol"
+)
+
+// Views accepted by --view.
+const (
+	viewFull     = "full"
+	viewSkeleton = "skeleton"
+)
+
+// writeSkeleton writes the skeleton of every counted file to outPath as
+// XML-tagged sections, in path order. Files without a skeleton go in whole.
+func writeSkeleton(outPath string, rootPath string, source count.Source, result *count.Result) error {
+	read := sourceReader(rootPath, source)
+	paths := make([]string, len(result.Files))
+	languages := make(map[string]string, len(result.Files))
+	for i, file := range result.Files {
+		paths[i] = file.Path
+		languages[file.Path] = file.Language
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		data, err := read(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		if view, ok := symbols.Skeleton(languages[path], data); ok {
+			data = view
+		}
+		b.WriteString(pack.Render(pack.FormatXML, path, string(data)))
+	}
+	if err := os.WriteFile(outPath, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("write skeleton: %w", err)
+	}
+	return nil
+}
+
+// sourceReader reads counted files by slash path from source, or from
+// rootPath on disk when there is none.
+func sourceReader(rootPath string, source count.Source) func(path string) ([]byte, error) {
+	if source != nil {
+		return source.Read
+	}
+	return func(path string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(rootPath, filepath.FromSlash(path)))
+	}
+}
+
+// symbolIndex outlines the counted files for --granularity symbol with the
+// scan's tokenizer, reading them from its source when it had one, and
+// groups the symbols by file.
+func symbolIndex(opts count.Options, result *count.Result) (map[string][]symbols.Symbol, error) {
+	outline, err := symbols.Run(symbols.Options{
+		Files:     result.Files,
+		Read:      sourceReader(opts.Root, opts.Source),
+		Tokenizer: opts.Tokenizer,
+		Sort:      symbols.SortFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string][]symbols.Symbol)
+	for _, sym := range outline.Symbols {
+		index[sym.File] = append(index[sym.File], sym)
+	}
+	return index, nil
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Napageneral/tokcount/internal/budget"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the project config files tokcount discovers, in the order
+// they are tried within a single directory.
+var FileNames = []string{".tokcount.yaml", ".tokcount.yml", ".tokcount.toml"}
+
+// Config is a project-level set of scan defaults. Unset fields fall back
+// to the CLI defaults; CLI flags always win.
+type Config struct {
+	Tokenizer     string       `yaml:"tokenizer,omitempty" toml:"tokenizer"`
+	TokenizerFile string       `yaml:"tokenizer_file,omitempty" toml:"tokenizer_file"`
+	Output        string       `yaml:"output,omitempty" toml:"output"`
+	Tree          *bool        `yaml:"tree,omitempty" toml:"tree"`
+	By            string       `yaml:"by,omitempty" toml:"by"`
+	View          string       `yaml:"view,omitempty" toml:"view"`
+	IgnoreFile    string       `yaml:"ignore_file,omitempty" toml:"ignore_file"`
+	Ignore        []string     `yaml:"ignore,omitempty" toml:"ignore"`
+	Include       []string     `yaml:"include,omitempty" toml:"include"`
+	MaxFileSize   ByteSize     `yaml:"max_file_size,omitempty" toml:"max_file_size"`
+	Budgets       budget.Rules `yaml:"budgets,omitempty" toml:"budgets"`
+	Pricing       Pricing      `yaml:"pricing,omitempty" toml:"pricing"`
+	Estimate      Estimate     `yaml:"estimate,omitempty" toml:"estimate"`
+	// ContextWindows are sizes ("200k") or model names, like
+	// --context-window.
+	ContextWindows []string `yaml:"context_windows,omitempty" toml:"context_windows"`
+
+	// Sources lists the config files merged into this config, outermost
+	// first.
+	Sources []string `yaml:"-" toml:"-"`
+}
+
+// Pricing configures the pricing section of the output.
+type Pricing struct {
+	// Section is none, intent, or catalog, like --pricing.
+	Section       string `yaml:"section,omitempty" toml:"section"`
+	USDPerMillion int    `yaml:"usd_per_million,omitempty" toml:"usd_per_million"`
+	// Catalog is a YAML/JSON price file layered over the built-in catalog.
+	Catalog string `yaml:"catalog,omitempty" toml:"catalog"`
+	// Models are priced in every scan, like --price-model.
+	Models       []string `yaml:"models,omitempty" toml:"models"`
+	PassesPerDay int      `yaml:"passes_per_day,omitempty" toml:"passes_per_day"`
+}
+
+// Estimate tunes the chars/token ratios of the estimate tokenizer.
+type Estimate struct {
+	// CharsPerToken applies to extensions missing from the ratio table.
+	CharsPerToken float64 `yaml:"chars_per_token,omitempty" toml:"chars_per_token"`
+	// Ratios override the built-in table, keyed by extension (".go").
+	Ratios map[string]float64 `yaml:"ratios,omitempty" toml:"ratios"`
+	// RatiosFile is a table written by tokcount calibrate; Ratios still
+	// win over it.
+	RatiosFile string `yaml:"ratios_file,omitempty" toml:"ratios_file"`
+}
+
+// Discover loads every config file from dir up to the filesystem root and
+// merges them so that files closer to dir take precedence.
+func Discover(dir string) (Config, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return Config{}, fmt.Errorf("resolve config dir: %w", err)
+	}
+
+	paths := make([]string, 0)
+	for current := abs; ; {
+		if path := findInDir(current); path != "" {
+			paths = append(paths, path)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	var merged Config
+	for i := len(paths) - 1; i >= 0; i-- {
+		cfg, err := Load(paths[i])
+		if err != nil {
+			return Config{}, err
+		}
+		merged = merged.Merge(cfg)
+	}
+	return merged, nil
+}
+
+// Load reads a single YAML or TOML config file. Relative ignore_file,
+// tokenizer_file, ratios_file, and catalog paths are resolved against the
+// config file's directory.
+func Load(path string) (Config, error) {
+	var cfg Config
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("read config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		if _, err := toml.Decode(string(raw), &cfg); err != nil {
+			return cfg, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	default:
+		if err := yaml.Unmarshal(raw, &cfg); err != nil {
+			return cfg, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+
+	if cfg.IgnoreFile != "" && !filepath.IsAbs(cfg.IgnoreFile) {
+		cfg.IgnoreFile = filepath.Join(filepath.Dir(path), cfg.IgnoreFile)
+	}
+	if cfg.TokenizerFile != "" && !filepath.IsAbs(cfg.TokenizerFile) {
+		cfg.TokenizerFile = filepath.Join(filepath.Dir(path), cfg.TokenizerFile)
+	}
+	if cfg.Estimate.RatiosFile != "" && !filepath.IsAbs(cfg.Estimate.RatiosFile) {
+		cfg.Estimate.RatiosFile = filepath.Join(filepath.Dir(path), cfg.Estimate.RatiosFile)
+	}
+	if cfg.Pricing.Catalog != "" && !filepath.IsAbs(cfg.Pricing.Catalog) {
+		cfg.Pricing.Catalog = filepath.Join(filepath.Dir(path), cfg.Pricing.Catalog)
+	}
+	cfg.Tokenizer = resolveTokenizerPath(cfg.Tokenizer, filepath.Dir(path))
+	cfg.Sources = []string{path}
+	return cfg, nil
+}
+
+// tokenizerFilePrefixes name tokenizers that load a model file.
+var tokenizerFilePrefixes = []string{"hf:", "spm:"}
+
+// resolveTokenizerPath makes the path of a file-backed tokenizer such as
+// "hf:./tokenizer.json" or "spm:./tokenizer.model" relative to dir. Each
+// entry of a comma-separated list is resolved.
+func resolveTokenizerPath(name string, dir string) string {
+	if strings.Contains(name, ",") {
+		parts := strings.Split(name, ",")
+		for i, part := range parts {
+			parts[i] = resolveTokenizerPath(strings.TrimSpace(part), dir)
+		}
+		return strings.Join(parts, ",")
+	}
+	for _, prefix := range tokenizerFilePrefixes {
+		if len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			path := name[len(prefix):]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			return name[:len(prefix)] + path
+		}
+	}
+	return name
+}
+
+// Merge overlays other onto c. Scalars set in other replace those in c;
+// pattern lists are concatenated and budgets are merged rule by rule.
+func (c Config) Merge(other Config) Config {
+	out := c
+	if other.Tokenizer != "" {
+		out.Tokenizer = other.Tokenizer
+	}
+	if other.TokenizerFile != "" {
+		out.TokenizerFile = other.TokenizerFile
+	}
+	if other.Output != "" {
+		out.Output = other.Output
+	}
+	if other.Tree != nil {
+		out.Tree = other.Tree
+	}
+	if other.By != "" {
+		out.By = other.By
+	}
+	if other.View != "" {
+		out.View = other.View
+	}
+	if other.IgnoreFile != "" {
+		out.IgnoreFile = other.IgnoreFile
+	}
+	if other.MaxFileSize > 0 {
+		out.MaxFileSize = other.MaxFileSize
+	}
+	if other.Pricing.USDPerMillion > 0 {
+		out.Pricing.USDPerMillion = other.Pricing.USDPerMillion
+	}
+	if len(other.ContextWindows) > 0 {
+		out.ContextWindows = other.ContextWindows
+	}
+	if other.Pricing.Section != "" {
+		out.Pricing.Section = other.Pricing.Section
+	}
+	if other.Pricing.Catalog != "" {
+		out.Pricing.Catalog = other.Pricing.Catalog
+	}
+	if len(other.Pricing.Models) > 0 {
+		out.Pricing.Models = other.Pricing.Models
+	}
+	if other.Pricing.PassesPerDay > 0 {
+		out.Pricing.PassesPerDay = other.Pricing.PassesPerDay
+	}
+	if other.Estimate.RatiosFile != "" {
+		out.Estimate.RatiosFile = other.Estimate.RatiosFile
+	}
+	if other.Estimate.CharsPerToken > 0 {
+		out.Estimate.CharsPerToken = other.Estimate.CharsPerToken
+	}
+	if len(other.Estimate.Ratios) > 0 {
+		ratios := make(map[string]float64, len(c.Estimate.Ratios)+len(other.Estimate.Ratios))
+		for ext, ratio := range c.Estimate.Ratios {
+			ratios[ext] = ratio
+		}
+		for ext, ratio := range other.Estimate.Ratios {
+			ratios[ext] = ratio
+		}
+		out.Estimate.Ratios = ratios
+	}
+	out.Ignore = append(append([]string(nil), c.Ignore...), other.Ignore...)
+	out.Include = append(append([]string(nil), c.Include...), other.Include...)
+	out.Budgets = c.Budgets.Merge(other.Budgets)
+	out.Sources = append(append([]string(nil), c.Sources...), other.Sources...)
+	return out
+}
+
+// YAML renders the config in .tokcount.yaml syntax.
+func (c Config) YAML() (string, error) {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func findInDir(dir string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// ByteSize is a size in bytes that parses suffixes such as "512KB",
+// "10MiB", or "1G".
+type ByteSize int64
+
+// ParseByteSize parses a plain byte count or a KB/MB/GB (decimal) or
+// KiB/MiB/GiB (binary) suffixed size.
+func ParseByteSize(raw string) (ByteSize, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+		{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9},
+		{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30},
+		{"b", 1},
+	}
+	multiplier := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			multiplier = unit.multiplier
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", raw)
+	}
+	return ByteSize(value * multiplier), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	parsed, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+func (b ByteSize) String() string {
+	return strconv.FormatInt(int64(b), 10)
+}
+
+// Set and Type let ByteSize be used as a pflag value.
+func (b *ByteSize) Set(raw string) error {
+	return b.UnmarshalText([]byte(raw))
+}
+
+func (b *ByteSize) Type() string {
+	return "size"
+}
+
+// Defaults mirrors the CLI flag defaults.
+func Defaults() Config {
+	tree := false
+	return Config{
+		Tokenizer:   "estimate",
+		Output:      "summary",
+		Tree:        &tree,
+		MaxFileSize: 10 * 1024 * 1024,
+	}
+}
+
+// WithDefaults fills unset fields of c from Defaults.
+func WithDefaults(c Config) Config {
+	sources := c.Sources
+	merged := Defaults().Merge(c)
+	merged.Sources = sources
+	return merged
+}
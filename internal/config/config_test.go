@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscover_MergesParentsWithNearestWinning(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(child, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := "tokenizer = \"openai\"\noutput = \"json\"\nignore = [\"*.md\"]\n\n[budgets]\ntotal = \"2M\"\nfile = 20000\n\n[estimate.ratios]\n\".go\" = 3.0\n\".md\" = 4.0\n"
+	if err := os.WriteFile(filepath.Join(root, ".tokcount.toml"), []byte(parent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nearest := "tokenizer: anthropic\nignore_file: .extraignore\nignore: [fixtures/]\nmax_file_size: 1MiB\nbudgets:\n  directories:\n    src/: 300k\nestimate:\n  ratios:\n    .go: 2.9\n"
+	if err := os.WriteFile(filepath.Join(child, ".tokcount.yaml"), []byte(nearest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Discover(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Tokenizer != "anthropic" {
+		t.Fatalf("expected nearest tokenizer to win, got %q", cfg.Tokenizer)
+	}
+	if cfg.Output != "json" {
+		t.Fatalf("expected parent output to be inherited, got %q", cfg.Output)
+	}
+	if len(cfg.Ignore) != 2 || cfg.Ignore[0] != "*.md" || cfg.Ignore[1] != "fixtures/" {
+		t.Fatalf("expected ignore patterns to be concatenated, got %v", cfg.Ignore)
+	}
+	if cfg.IgnoreFile != filepath.Join(child, ".extraignore") {
+		t.Fatalf("expected ignore_file relative to its config, got %q", cfg.IgnoreFile)
+	}
+	if cfg.MaxFileSize != 1<<20 {
+		t.Fatalf("expected 1MiB max file size, got %d", cfg.MaxFileSize)
+	}
+	if cfg.Budgets.Total != 2_000_000 || cfg.Budgets.File != 20_000 || cfg.Budgets.Directories["src/"] != 300_000 {
+		t.Fatalf("unexpected merged budgets: %+v", cfg.Budgets)
+	}
+	if cfg.Estimate.Ratios[".go"] != 2.9 || cfg.Estimate.Ratios[".md"] != 4.0 {
+		t.Fatalf("expected estimate ratios merged per extension, got %v", cfg.Estimate.Ratios)
+	}
+	if len(cfg.Sources) != 2 {
+		t.Fatalf("expected 2 config sources, got %v", cfg.Sources)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]ByteSize{
+		"1024":  1024,
+		"512KB": 512_000,
+		"10MiB": 10 << 20,
+		"2m":    2 << 20,
+	}
+	for raw, want := range cases {
+		got, err := ParseByteSize(raw)
+		if err != nil {
+			t.Fatalf("ParseByteSize(%q): %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("ParseByteSize(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func TestLoad_ResolvesTokenizerModelPath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".tokcount.yaml")
+	if err := os.WriteFile(path, []byte("tokenizer: hf:models/tokenizer.json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hf:" + filepath.Join(dir, "models", "tokenizer.json"); cfg.Tokenizer != want {
+		t.Fatalf("expected %q, got %q", want, cfg.Tokenizer)
+	}
+}
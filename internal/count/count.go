@@ -7,7 +7,9 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 
+	"github.com/Napageneral/tokcount/internal/cache"
 	"github.com/Napageneral/tokcount/internal/ignore"
 	"github.com/Napageneral/tokcount/internal/tokenizer"
 )
@@ -20,21 +22,145 @@ type Options struct {
 	Tokenizer    tokenizer.Tokenizer
 	IgnoreSpec   *ignore.Spec
 	MaxFileBytes int64
+	// Concurrency is the number of tokenizing workers; <= 0 uses GOMAXPROCS.
+	Concurrency int
+	// Cache, when set, skips tokenizing content that was counted before.
+	Cache *cache.Cache
+	// Source, when set, replaces the filesystem walk (e.g. git index or
+	// revision listings). Paths are relative to Root.
+	Source Source
+	// Revision labels the result when Source reads a git revision.
+	Revision string
+	// EstimateIgnored adds a chars/token estimate of the tokens in
+	// pattern-ignored and oversized files to the ignored breakdown.
+	EstimateIgnored bool
+	// Estimator makes that estimate. Nil uses Tokenizer when it is an
+	// estimate, else the built-in per-extension table.
+	Estimator *tokenizer.EstimateTokenizer
+	// Compare lists more tokenizers to count every file with, sharing the
+	// walk and a single read. Tokenizer still drives TotalTokens.
+	Compare []tokenizer.Tokenizer
+	// Skeleton, when set, also counts each file as it transforms it
+	// (typically with function bodies elided); ok is false to count the
+	// file as it is.
+	Skeleton func(language string, content []byte) (view []byte, ok bool)
+}
+
+// Source enumerates files to count in place of walking Root.
+type Source interface {
+	// Walk calls fn for every candidate file in a deterministic order.
+	Walk(fn func(entry SourceEntry) error) error
+	// Read returns the content of a file yielded by Walk. It may be
+	// called concurrently.
+	Read(relPath string) ([]byte, error)
+}
+
+// SourceEntry is a file listed by a Source.
+type SourceEntry struct {
+	RelPath string
+	Size    int64
 }
 
 // Result is the normalized token counting output.
 type Result struct {
-	Repository      string         `json:"repository"`
-	Tokenizer       string         `json:"tokenizer"`
-	TokenizerDetail string         `json:"-"`
-	TotalTokens     int            `json:"total_tokens"`
-	TotalFiles      int            `json:"total_files"`
-	IgnoredFiles    int            `json:"ignored_files"`
-	TotalLines      int            `json:"total_lines"`
+	Repository      string `json:"repository"`
+	Revision        string `json:"revision,omitempty"`
+	Tokenizer       string `json:"tokenizer"`
+	TokenizerDetail string `json:"-"`
+	TotalTokens     int    `json:"total_tokens"`
+	TotalFiles      int    `json:"total_files"`
+	IgnoredFiles    int    `json:"ignored_files"`
+	// Ignored breaks IgnoredFiles down by Reason* constant.
+	Ignored    map[string]IgnoredStat `json:"-"`
+	TotalLines int                    `json:"total_lines"`
+	// TokenSplit and LineSplit divide TotalTokens and TotalLines by kind.
+	TokenSplit      Split          `json:"token_split"`
+	LineSplit       Split          `json:"line_split"`
 	DirectoryTokens map[string]int `json:"-"`
+	// Languages rolls counted files up by FileStat.Language.
+	Languages map[string]LanguageStat `json:"-"`
+	Files     []FileStat              `json:"-"`
+	// Comparison holds one total per tokenizer, Tokenizer first, when
+	// Options.Compare is set.
+	Comparison []TokenizerTotal `json:"-"`
+	// Skeleton totals the transformed view when Options.Skeleton is set.
+	Skeleton *SkeletonStat `json:"-"`
+}
+
+// SkeletonStat totals the skeleton view of the counted files.
+type SkeletonStat struct {
+	Tokens int `json:"tokens"`
+	// Files counts the files with at least one body elided; the rest
+	// count in full.
+	Files int `json:"files"`
+}
+
+// TokenizerTotal is one tokenizer's count in a comparison scan.
+type TokenizerTotal struct {
+	// Name is the tokenizer.Label, unique within a scan.
+	Name            string
+	Description     string
+	TotalTokens     int
+	DirectoryTokens map[string]int
+}
+
+// IgnoredStat totals the files left out of the count for one reason.
+type IgnoredStat struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+	// EstimatedTokens is set only when Options.EstimateIgnored is true.
+	// Binary files count none.
+	EstimatedTokens int `json:"estimated_tokens,omitempty"`
+}
+
+// LanguageStat totals the counted files of one language.
+type LanguageStat struct {
+	Files  int   `json:"files"`
+	Tokens int   `json:"tokens"`
+	Lines  int   `json:"lines"`
+	Bytes  int64 `json:"bytes"`
+}
+
+// Split divides a file's tokens or lines between code, comments,
+// docstrings and blank lines.
+type Split struct {
+	Code      int `json:"code"`
+	Comment   int `json:"comment"`
+	Docstring int `json:"docstring"`
+	Blank     int `json:"blank"`
+}
+
+func (s *Split) add(other Split) {
+	s.Code += other.Code
+	s.Comment += other.Comment
+	s.Docstring += other.Docstring
+	s.Blank += other.Blank
+}
+
+// FileStat is the token count for a single counted file.
+type FileStat struct {
+	Path      string `json:"path"`
+	Tokens    int    `json:"tokens"`
+	Bytes     int64  `json:"bytes"`
+	Lines     int    `json:"lines"`
+	Extension string `json:"extension"`
+	// Language is detected from the file name, extension, and shebang.
+	Language string `json:"language"`
+	// TokenSplit and LineSplit divide Tokens and Lines by kind; languages
+	// without comment rules are all code and blank lines.
+	TokenSplit Split `json:"token_split"`
+	LineSplit  Split `json:"line_split"`
+	// Counts maps tokenizer label to tokens in a comparison scan.
+	Counts map[string]int `json:"counts,omitempty"`
+	// SkeletonTokens counts the file's skeleton view when
+	// Options.Skeleton is set.
+	SkeletonTokens int `json:"skeleton_tokens,omitempty"`
 }
 
 // Run walks the repository and counts tokens by file and directory.
+//
+// The walk feeds a bounded pool of tokenizing workers; results are merged in
+// walk order so the output does not depend on Concurrency.
 func Run(opts Options) (*Result, error) {
 	if opts.Tokenizer == nil {
 		return nil, fmt.Errorf("tokenizer is required")
@@ -48,15 +174,125 @@ func Run(opts Options) (*Result, error) {
 	if opts.MaxFileBytes <= 0 {
 		opts.MaxFileBytes = defaultMaxFileBytes
 	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = runtime.GOMAXPROCS(0)
+	}
+	if !opts.EstimateIgnored {
+		opts.Estimator = nil
+	} else if opts.Estimator == nil {
+		if est, ok := opts.Tokenizer.(*tokenizer.EstimateTokenizer); ok {
+			opts.Estimator = est
+		} else if opts.Estimator, err = tokenizer.NewCalibratedEstimate(0, nil); err != nil {
+			return nil, err
+		}
+	}
 
 	result := &Result{
 		Repository:      root,
+		Revision:        opts.Revision,
 		Tokenizer:       opts.Tokenizer.Name(),
 		TokenizerDetail: opts.Tokenizer.Description(),
 		DirectoryTokens: map[string]int{".": 0},
+		Languages:       make(map[string]LanguageStat),
+		Ignored:         make(map[string]IgnoredStat),
+	}
+
+	toks := append([]tokenizer.Tokenizer{opts.Tokenizer}, opts.Compare...)
+	if len(opts.Compare) > 0 {
+		for _, tok := range toks {
+			result.Comparison = append(result.Comparison, TokenizerTotal{
+				Name:            tokenizer.Label(tok),
+				Description:     tok.Description(),
+				DirectoryTokens: map[string]int{".": 0},
+			})
+		}
+	}
+
+	if opts.Skeleton != nil {
+		result.Skeleton = &SkeletonStat{}
+	}
+
+	pool := newWorkerPool(opts.Concurrency, toks, opts.Cache, opts.Skeleton)
+
+	var walkErr error
+	if opts.Source != nil {
+		walkErr = walkSource(root, opts, result, pool)
+	} else {
+		walkErr = walkFilesystem(root, opts, result, pool)
+	}
+	outcomes := pool.wait()
+	if walkErr != nil {
+		return nil, fmt.Errorf("walk repository: %w", walkErr)
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.err != nil {
+			return nil, fmt.Errorf("walk repository: %w", outcome.err)
+		}
+		if outcome.vanished {
+			continue
+		}
+		if outcome.unreadable {
+			result.addIgnored(ReasonUnreadable, 1, outcome.size)
+			continue
+		}
+		if outcome.binary {
+			result.addIgnored(ReasonBinary, 1, outcome.size)
+			continue
+		}
+
+		stat := outcome.stat
+		result.TotalTokens += stat.Tokens
+		result.TotalFiles++
+		result.TotalLines += stat.Lines
+		result.TokenSplit.add(stat.TokenSplit)
+		result.LineSplit.add(stat.LineSplit)
+		addTokensToDirs(result.DirectoryTokens, stat.Path, stat.Tokens)
+		lang := result.Languages[stat.Language]
+		lang.Files++
+		lang.Tokens += stat.Tokens
+		lang.Lines += stat.Lines
+		lang.Bytes += stat.Bytes
+		result.Languages[stat.Language] = lang
+		if result.Skeleton != nil {
+			result.Skeleton.Tokens += stat.SkeletonTokens
+			if outcome.elided {
+				result.Skeleton.Files++
+			}
+		}
+		for i := range result.Comparison {
+			total := &result.Comparison[i]
+			tokens := stat.Counts[total.Name]
+			total.TotalTokens += tokens
+			addTokensToDirs(total.DirectoryTokens, stat.Path, tokens)
+		}
+		result.Files = append(result.Files, stat)
+	}
+
+	result.DirectoryTokens["."] = result.TotalTokens
+	for i := range result.Comparison {
+		result.Comparison[i].DirectoryTokens["."] = result.Comparison[i].TotalTokens
 	}
+	return result, nil
+}
+
+func (r *Result) addIgnored(reason string, files int, bytes int64) {
+	stat := r.Ignored[reason]
+	stat.Files += files
+	stat.Bytes += bytes
+	r.Ignored[reason] = stat
+	r.IgnoredFiles += files
+}
+
+// addIgnoredEstimate records the estimated tokens of ignored files.
+func (r *Result) addIgnoredEstimate(reason string, tokens int) {
+	stat := r.Ignored[reason]
+	stat.EstimatedTokens += tokens
+	r.Ignored[reason] = stat
+}
 
-	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+func walkFilesystem(root string, opts Options, result *Result, pool *workerPool) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			if errors.Is(walkErr, os.ErrNotExist) || errors.Is(walkErr, os.ErrPermission) {
 				return nil
@@ -68,13 +304,28 @@ func Run(opts Options) (*Result, error) {
 		}
 
 		isDir := d.IsDir()
-		if opts.IgnoreSpec != nil && opts.IgnoreSpec.MatchPath(path, isDir) {
-			if isDir {
-				result.IgnoredFiles += countFilesUnderDir(path)
-				return fs.SkipDir
+		if opts.IgnoreSpec != nil {
+			if ignored, match := opts.IgnoreSpec.MatchPathHow(path, isDir); ignored {
+				reason := ReasonPattern
+				if match != nil && match.NotIncluded {
+					reason = ReasonNotIncluded
+				}
+				if isDir {
+					files, bytes, tokens := countFilesUnderDir(path, opts.Estimator)
+					result.addIgnored(reason, files, bytes)
+					result.addIgnoredEstimate(reason, tokens)
+					return fs.SkipDir
+				}
+				var size int64
+				if info, err := d.Info(); err == nil {
+					size = info.Size()
+				}
+				result.addIgnored(reason, 1, size)
+				if opts.Estimator != nil {
+					result.addIgnoredEstimate(reason, ignoredTokens(opts.Estimator, path, os.ReadFile))
+				}
+				return nil
 			}
-			result.IgnoredFiles++
-			return nil
 		}
 
 		if isDir {
@@ -83,47 +334,93 @@ func Run(opts Options) (*Result, error) {
 
 		info, err := d.Info()
 		if err != nil {
-			if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			if errors.Is(err, os.ErrPermission) {
+				result.addIgnored(ReasonUnreadable, 1, 0)
 				return nil
 			}
 			return err
 		}
 
 		if info.Size() > opts.MaxFileBytes {
-			result.IgnoredFiles++
+			result.addIgnored(ReasonOversized, 1, info.Size())
+			if opts.Estimator != nil {
+				result.addIgnoredEstimate(ReasonOversized, ignoredTokens(opts.Estimator, path, os.ReadFile))
+			}
 			return nil
 		}
 
-		data, err := os.ReadFile(path)
+		relPath, err := filepath.Rel(root, path)
 		if err != nil {
-			if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			return nil
+		}
+
+		pool.submit(relPath, info.Size(), func() ([]byte, error) {
+			return os.ReadFile(path)
+		})
+		return nil
+	})
+}
+
+func walkSource(root string, opts Options, result *Result, pool *workerPool) error {
+	ignoredDirs := make(map[string]bool)
+	return opts.Source.Walk(func(entry SourceEntry) error {
+		relPath := filepath.FromSlash(entry.RelPath)
+		if opts.IgnoreSpec != nil {
+			if reason := sourcePathIgnored(opts.IgnoreSpec, root, relPath, ignoredDirs); reason != "" {
+				result.addIgnored(reason, 1, entry.Size)
+				if opts.Estimator != nil {
+					result.addIgnoredEstimate(reason, ignoredTokens(opts.Estimator, entry.RelPath, opts.Source.Read))
+				}
 				return nil
 			}
-			return err
 		}
-
-		if isLikelyBinary(data) {
-			result.IgnoredFiles++
+		if entry.Size > opts.MaxFileBytes {
+			result.addIgnored(ReasonOversized, 1, entry.Size)
+			if opts.Estimator != nil {
+				result.addIgnoredEstimate(ReasonOversized, ignoredTokens(opts.Estimator, entry.RelPath, opts.Source.Read))
+			}
 			return nil
 		}
 
-		tokens := opts.Tokenizer.Count(string(data))
-		result.TotalTokens += tokens
-		result.TotalFiles++
-		result.TotalLines += countLines(data)
-
-		relPath, err := filepath.Rel(root, path)
-		if err == nil {
-			addTokensToDirs(result.DirectoryTokens, relPath, tokens)
-		}
+		pool.submit(relPath, entry.Size, func() ([]byte, error) {
+			return opts.Source.Read(entry.RelPath)
+		})
 		return nil
 	})
-	if err != nil {
-		return nil, fmt.Errorf("walk repository: %w", err)
-	}
+}
 
-	result.DirectoryTokens["."] = result.TotalTokens
-	return result, nil
+// sourcePathIgnored applies the spec to a listed file and each of its parent
+// directories, matching how the filesystem walk prunes ignored directories.
+// It returns the ignore reason, or "" when the file is kept.
+func sourcePathIgnored(spec *ignore.Spec, root string, relPath string, ignoredDirs map[string]bool) string {
+	dir := filepath.Dir(relPath)
+	parents := make([]string, 0)
+	for dir != "." && dir != "" {
+		parents = append(parents, dir)
+		dir = filepath.Dir(dir)
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		ignored, ok := ignoredDirs[parents[i]]
+		if !ok {
+			ignored = spec.MatchPath(filepath.Join(root, parents[i]), true)
+			ignoredDirs[parents[i]] = ignored
+		}
+		if ignored {
+			return ReasonPattern
+		}
+	}
+	ignored, match := spec.MatchPathHow(filepath.Join(root, relPath), false)
+	switch {
+	case !ignored:
+		return ""
+	case match != nil && match.NotIncluded:
+		return ReasonNotIncluded
+	default:
+		return ReasonPattern
+	}
 }
 
 func addTokensToDirs(dirTotals map[string]int, relPath string, tokens int) {
@@ -145,22 +442,39 @@ func addTokensToDirs(dirTotals map[string]int, relPath string, tokens int) {
 	}
 }
 
-func countFilesUnderDir(dir string) int {
-	total := 0
+// countFilesUnderDir totals the files and bytes under an ignored directory.
+// With est set it also estimates their tokens with ignoredTokens.
+func countFilesUnderDir(dir string, est *tokenizer.EstimateTokenizer) (int, int64, int) {
+	total, tokens := 0, 0
+	var size int64
 	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
-				return nil
-			}
 			return nil
 		}
 		if d.IsDir() {
 			return nil
 		}
 		total++
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		if est != nil {
+			tokens += ignoredTokens(est, path, os.ReadFile)
+		}
 		return nil
 	})
-	return total
+	return total, size, tokens
+}
+
+// ignoredTokens estimates the tokens an ignored file would count for: none
+// when it is unreadable or binary by the same test as counted files, else
+// its size at the chars/token ratio of its extension.
+func ignoredTokens(est *tokenizer.EstimateTokenizer, path string, read func(string) ([]byte, error)) int {
+	data, err := read(path)
+	if err != nil || isLikelyBinary(data) {
+		return 0
+	}
+	return est.CountPath(path, string(data))
 }
 
 func isLikelyBinary(data []byte) bool {
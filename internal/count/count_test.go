@@ -1,10 +1,15 @@
 package count
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/Napageneral/tokcount/internal/cache"
 	"github.com/Napageneral/tokcount/internal/ignore"
 	"github.com/Napageneral/tokcount/internal/tokenizer"
 )
@@ -62,3 +67,445 @@ func TestRun_CountsAndIgnores(t *testing.T) {
 		t.Fatalf("expected src directory tokens to be > 0")
 	}
 }
+
+func TestRun_RecordsFileStats(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("package main\n\nfunc main() {}\n")
+	if err := os.WriteFile(filepath.Join(root, "src", "main.go"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("# demo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Run(Options{
+		Root:      root,
+		Tokenizer: tokenizer.NewEstimate(3.5),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Files) != 2 {
+		t.Fatalf("expected 2 file stats, got %d", len(result.Files))
+	}
+
+	var mainStat *FileStat
+	for i := range result.Files {
+		if result.Files[i].Path == "src/main.go" {
+			mainStat = &result.Files[i]
+		}
+	}
+	if mainStat == nil {
+		t.Fatalf("expected src/main.go in file stats, got %+v", result.Files)
+	}
+	if mainStat.Bytes != int64(len(content)) {
+		t.Fatalf("expected %d bytes, got %d", len(content), mainStat.Bytes)
+	}
+	if mainStat.Lines != 4 {
+		t.Fatalf("expected 4 lines, got %d", mainStat.Lines)
+	}
+	if mainStat.Extension != ".go" {
+		t.Fatalf("expected .go extension, got %q", mainStat.Extension)
+	}
+	if mainStat.Tokens != result.DirectoryTokens["src"] {
+		t.Fatalf("expected file tokens %d to equal src rollup %d", mainStat.Tokens, result.DirectoryTokens["src"])
+	}
+}
+
+func TestRun_ConcurrencyIsDeterministic(t *testing.T) {
+	root := t.TempDir()
+
+	for i := 0; i < 40; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%d", i%5))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		body := []byte(strings.Repeat(fmt.Sprintf("line %d\n", i), i+1))
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%02d.txt", i)), body, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(concurrency int) *Result {
+		t.Helper()
+		result, err := Run(Options{
+			Root:        root,
+			Tokenizer:   tokenizer.NewEstimate(3.5),
+			Concurrency: concurrency,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	serial := run(1)
+	for _, n := range []int{2, 8, 64} {
+		parallel := run(n)
+		if !reflect.DeepEqual(serial, parallel) {
+			t.Fatalf("concurrency %d produced different result than serial run", n)
+		}
+	}
+}
+
+func TestRun_IgnoredBreakdownByReason(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "node_modules", "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		filepath.Join("node_modules", "pkg", "index.js"): []byte("module.exports = {};\n"),
+		filepath.Join("node_modules", "pkg", "util.js"):  []byte("exports.x = 1;\n"),
+		"data.csv":  []byte("a,b,c\n1,2,3\n"),
+		"big.txt":   []byte(strings.Repeat("x", 64)),
+		"blob.dat2": {0x00, 0x01, 0x02, 0x03},
+		"main.go":   []byte("package main\n"),
+	}
+	for rel, content := range files {
+		if err := os.WriteFile(filepath.Join(root, rel), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec, err := ignore.LoadSpec(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimator, err := tokenizer.NewCalibratedEstimate(0, map[string]float64{".js": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Run(Options{
+		Root:            root,
+		Tokenizer:       tokenizer.NewEstimate(3.5),
+		IgnoreSpec:      spec,
+		MaxFileBytes:    32,
+		EstimateIgnored: true,
+		Estimator:       estimator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := result.Ignored[ReasonPattern]
+	if pattern.Files != 3 {
+		t.Fatalf("expected 3 pattern-ignored files, got %+v", pattern)
+	}
+	wantBytes := int64(len(files[filepath.Join("node_modules", "pkg", "index.js")]) + len(files[filepath.Join("node_modules", "pkg", "util.js")]) + len(files["data.csv"]))
+	if pattern.Bytes != wantBytes {
+		t.Fatalf("expected %d pattern-ignored bytes, got %d", wantBytes, pattern.Bytes)
+	}
+	if pattern.EstimatedTokens <= 0 {
+		t.Fatalf("expected estimated tokens for pattern-ignored files")
+	}
+	if got := result.Ignored[ReasonOversized]; got.Files != 1 || got.Bytes != 64 {
+		t.Fatalf("expected one 64-byte oversized file, got %+v", got)
+	}
+	if got := result.Ignored[ReasonBinary]; got.Files != 1 || got.EstimatedTokens != 0 {
+		t.Fatalf("expected one binary file without a token estimate, got %+v", got)
+	}
+	if result.IgnoredFiles != 5 {
+		t.Fatalf("expected 5 ignored files in total, got %d", result.IgnoredFiles)
+	}
+}
+
+func TestRun_EstimateIgnoredSkipsBinaryFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "node_modules", "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := []byte("module.exports = {};\n")
+	files := map[string][]byte{
+		filepath.Join("node_modules", "pkg", "index.js"): script,
+		filepath.Join("node_modules", "pkg", "logo.png"): append([]byte{0x89, 'P', 'N', 'G', 0x00}, make([]byte, 512)...),
+		// A NUL past the first 4 KiB still makes the file binary, as it
+		// would when counted.
+		"huge.bin": append(bytes.Repeat([]byte("a"), 5000), 0x00),
+	}
+	for rel, content := range files {
+		if err := os.WriteFile(filepath.Join(root, rel), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec, err := ignore.LoadSpec(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimator, err := tokenizer.NewCalibratedEstimate(0, map[string]float64{".js": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Run(Options{
+		Root:            root,
+		Tokenizer:       tokenizer.NewEstimate(3.5),
+		IgnoreSpec:      spec,
+		MaxFileBytes:    32,
+		EstimateIgnored: true,
+		Estimator:       estimator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := result.Ignored[ReasonPattern]
+	if pattern.Files != 2 || pattern.Bytes != int64(len(script)+517) {
+		t.Fatalf("expected both node_modules files in the ignored totals, got %+v", pattern)
+	}
+	if want := (len(script) + 1) / 2; pattern.EstimatedTokens != want {
+		t.Fatalf("expected %d estimated tokens from index.js alone at its .js ratio, got %d", want, pattern.EstimatedTokens)
+	}
+	if got := result.Ignored[ReasonOversized]; got.Files != 1 || got.EstimatedTokens != 0 {
+		t.Fatalf("expected an oversized binary file without a token estimate, got %+v", got)
+	}
+}
+
+func TestRun_ComparesTokenizers(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "main.txt"), []byte(strings.Repeat("x", 140)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Run(Options{
+		Root:      root,
+		Tokenizer: tokenizer.NewEstimate(3.5),
+		Compare:   []tokenizer.Tokenizer{namedEstimate{tokenizer.NewEstimate(7)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.TotalTokens != 40 {
+		t.Fatalf("expected primary total of 40 tokens, got %d", result.TotalTokens)
+	}
+	if len(result.Comparison) != 2 {
+		t.Fatalf("expected 2 tokenizer totals, got %+v", result.Comparison)
+	}
+	primary, compared := result.Comparison[0], result.Comparison[1]
+	if primary.Name != "estimate" || primary.TotalTokens != result.TotalTokens {
+		t.Fatalf("expected the primary tokenizer first, got %+v", primary)
+	}
+	if compared.Name != "half" || compared.TotalTokens != 20 || compared.DirectoryTokens["src"] != 20 {
+		t.Fatalf("expected 20 tokens for the compared tokenizer, got %+v", compared)
+	}
+	want := map[string]int{"estimate": 40, "half": 20}
+	if got := result.Files[0].Counts; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected file counts %v, got %v", want, got)
+	}
+}
+
+func TestRun_ComparesTokenizerFilesOfOneKind(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("the cat sat\nhello world\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testdata, err := filepath.Abs(filepath.Join("..", "tokenizer", "testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []string{
+		"hf:" + filepath.Join(testdata, "gpt2-style.json"),
+		"hf:" + filepath.Join(testdata, "mistral-style.json"),
+		"spm:" + filepath.Join(testdata, "unigram.model"),
+		"spm:" + filepath.Join(testdata, "bpe.model"),
+	}
+	toks, err := tokenizer.NewList(strings.Join(entries, ","), tokenizer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Run(Options{Root: root, Tokenizer: toks[0], Compare: toks[1:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Comparison) != len(entries) {
+		t.Fatalf("expected %d tokenizer totals, got %+v", len(entries), result.Comparison)
+	}
+	counts := result.Files[0].Counts
+	for i, entry := range entries {
+		label := filepath.ToSlash(entry)
+		if result.Comparison[i].Name != label {
+			t.Fatalf("expected total %d to be labeled %s, got %s", i, label, result.Comparison[i].Name)
+		}
+		if counts[label] != result.Comparison[i].TotalTokens {
+			t.Fatalf("expected %s to count %d tokens, got %v", label, result.Comparison[i].TotalTokens, counts)
+		}
+	}
+	if len(counts) != len(entries) {
+		t.Fatalf("expected one count per file tokenizer, got %v", counts)
+	}
+}
+
+func TestRun_RollsUpLanguages(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":    "package main\n\nfunc main() {}\n",
+		"util.go":    "package main\n",
+		"Makefile":   "build:\n\tgo build\n",
+		"bin/deploy": "#!/usr/bin/env bash\necho hi\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Run(Options{Root: root, Tokenizer: tokenizer.NewEstimate(3.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goStat := result.Languages["Go"]
+	if goStat.Files != 2 || goStat.Lines != 6 {
+		t.Fatalf("expected 2 Go files with 6 lines, got %+v", goStat)
+	}
+	if result.Languages["Makefile"].Files != 1 || result.Languages["Shell"].Files != 1 {
+		t.Fatalf("expected Makefile and Shell by filename and shebang, got %+v", result.Languages)
+	}
+	total := 0
+	for _, stat := range result.Languages {
+		total += stat.Tokens
+	}
+	if total != result.TotalTokens {
+		t.Fatalf("expected language tokens to sum to %d, got %d", result.TotalTokens, total)
+	}
+}
+
+func TestRun_SplitsCodeAndComments(t *testing.T) {
+	root := t.TempDir()
+	src := "// Package main runs.\npackage main\n\nfunc main() {\n\t// TODO: everything\n}\n"
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Run(Options{Root: root, Tokenizer: tokenizer.NewEstimate(3.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantLines := Split{Code: 3, Comment: 1, Docstring: 1, Blank: 2}
+	if result.LineSplit != wantLines {
+		t.Fatalf("expected line split %+v, got %+v", wantLines, result.LineSplit)
+	}
+	tokens := result.TokenSplit
+	if tokens.Comment == 0 || tokens.Docstring == 0 || tokens.Code == 0 {
+		t.Fatalf("expected tokens of every kind, got %+v", tokens)
+	}
+	if sum := tokens.Code + tokens.Comment + tokens.Docstring + tokens.Blank; sum != result.TotalTokens {
+		t.Fatalf("expected the token split to sum to %d, got %d", result.TotalTokens, sum)
+	}
+}
+
+func TestRun_CountsSkeletonView(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":   "package main\n\nfunc main() {\n\tprintln(\"a long body that the skeleton drops\")\n}\n",
+		"lib.rs":    "fn f() { 1 }\n",
+		"README.md": "# readme\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Run(Options{
+		Root:      root,
+		Tokenizer: byteTokenizer{},
+		Cache:     store,
+		Skeleton: func(language string, content []byte) ([]byte, bool) {
+			switch language {
+			case "Go":
+				return []byte("package main\n\nfunc main() { ... }\n"), true
+			case "Rust":
+				// An elided body can cost as much as the stub.
+				return []byte("fn f() {...}\n"), true
+			}
+			return nil, false
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Skeleton == nil || result.Skeleton.Files != 2 {
+		t.Fatalf("expected two files with bodies elided, got %+v", result.Skeleton)
+	}
+	for _, file := range result.Files {
+		switch file.Path {
+		case "main.go":
+			if file.SkeletonTokens == 0 || file.SkeletonTokens >= file.Tokens {
+				t.Fatalf("expected main.go's skeleton to be smaller than %d tokens, got %d", file.Tokens, file.SkeletonTokens)
+			}
+		case "lib.rs", "README.md":
+			if file.SkeletonTokens != file.Tokens {
+				t.Fatalf("expected %s's skeleton to match its %d tokens, got %d", file.Path, file.Tokens, file.SkeletonTokens)
+			}
+		}
+	}
+	if result.Skeleton.Tokens >= result.TotalTokens {
+		t.Fatalf("expected the skeleton total below %d, got %d", result.TotalTokens, result.Skeleton.Tokens)
+	}
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != len(files) {
+		t.Fatalf("expected one cache entry per file, got %d", stats.Entries)
+	}
+}
+
+func TestRun_CachesOneEntryPerFile(t *testing.T) {
+	root := t.TempDir()
+	src := "// Package main runs.\npackage main\n\n// main is empty.\nfunc main() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Run(Options{Root: root, Tokenizer: byteTokenizer{}, Cache: store}); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1 {
+		t.Fatalf("expected one cache entry for one file, got %d", stats.Entries)
+	}
+}
+
+// byteTokenizer counts one token per byte and is not path-dependent, so
+// counts go through the cache.
+type byteTokenizer struct{}
+
+func (byteTokenizer) Count(text string) int { return len(text) }
+func (byteTokenizer) Name() string          { return "bytes" }
+func (byteTokenizer) Description() string   { return "bytes (test)" }
+
+// namedEstimate renames an estimate so two can be compared.
+type namedEstimate struct {
+	*tokenizer.EstimateTokenizer
+}
+
+func (namedEstimate) Name() string { return "half" }
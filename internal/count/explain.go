@@ -0,0 +1,138 @@
+package count
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/ignore"
+)
+
+// Explanation statuses.
+const (
+	StatusCounted = "counted"
+	StatusIgnored = "ignored"
+	StatusSkipped = "skipped"
+)
+
+// Reasons a file is not counted.
+const (
+	ReasonPattern     = "pattern"
+	ReasonNotIncluded = "not_included"
+	ReasonOversized   = "oversized"
+	ReasonBinary      = "binary"
+	ReasonUnreadable  = "unreadable"
+)
+
+// IgnoreReasons lists every reason in reporting order.
+var IgnoreReasons = []string{ReasonPattern, ReasonNotIncluded, ReasonOversized, ReasonBinary, ReasonUnreadable}
+
+// Explanation reports why a single path was counted or left out.
+type Explanation struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// Match is the deciding ignore rule, including a negated rule that
+	// re-included a counted path.
+	Match *ignore.Match `json:"match,omitempty"`
+	// Directory is set when an ignored parent directory pruned the path.
+	Directory string `json:"directory,omitempty"`
+	Bytes     int64  `json:"bytes"`
+	Limit     int64  `json:"limit,omitempty"`
+}
+
+// Explain applies the same checks as Run to one path, in the same order:
+// ignored parent directories, the path's own ignore rules, the size cap,
+// and the binary heuristic.
+func Explain(opts Options, absPath string) (Explanation, error) {
+	root, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return Explanation{}, fmt.Errorf("resolve root path: %w", err)
+	}
+	absPath, err = filepath.Abs(absPath)
+	if err != nil {
+		return Explanation{}, fmt.Errorf("resolve path: %w", err)
+	}
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = defaultMaxFileBytes
+	}
+
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return Explanation{}, fmt.Errorf("%s is outside %s", absPath, root)
+	}
+	exp := Explanation{Path: filepath.ToSlash(relPath)}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return Explanation{}, err
+	}
+	if info.IsDir() {
+		return Explanation{}, fmt.Errorf("%s is a directory", exp.Path)
+	}
+	exp.Bytes = info.Size()
+
+	if opts.IgnoreSpec != nil {
+		parents := make([]string, 0)
+		for dir := filepath.Dir(absPath); dir != root && isUnder(root, dir); dir = filepath.Dir(dir) {
+			parents = append(parents, dir)
+		}
+		for i := len(parents) - 1; i >= 0; i-- {
+			if ignored, match := opts.IgnoreSpec.MatchPathHow(parents[i], true); ignored {
+				rel, _ := filepath.Rel(root, parents[i])
+				exp.Status = StatusIgnored
+				exp.Reason = ReasonPattern
+				exp.Match = match
+				exp.Directory = filepath.ToSlash(rel) + "/"
+				return exp, nil
+			}
+		}
+
+		ignored, match := opts.IgnoreSpec.MatchPathHow(absPath, false)
+		exp.Match = match
+		if ignored {
+			exp.Status = StatusIgnored
+			exp.Reason = ReasonPattern
+			if match != nil && match.NotIncluded {
+				exp.Reason = ReasonNotIncluded
+				exp.Match = nil
+			}
+			return exp, nil
+		}
+	}
+
+	if info.Size() > opts.MaxFileBytes {
+		exp.Status = StatusSkipped
+		exp.Reason = ReasonOversized
+		exp.Limit = opts.MaxFileBytes
+		return exp, nil
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			exp.Status = StatusSkipped
+			exp.Reason = ReasonUnreadable
+			return exp, nil
+		}
+		return Explanation{}, err
+	}
+	if isLikelyBinary(data) {
+		exp.Status = StatusSkipped
+		exp.Reason = ReasonBinary
+		return exp, nil
+	}
+
+	exp.Status = StatusCounted
+	return exp, nil
+}
+
+func isUnder(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,76 @@
+package count
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/ignore"
+)
+
+func TestExplain_ReportsDecidingRule(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string][]byte{
+		".gitignore":          []byte("*.log\n!keep.log\n"),
+		"src/main.go":         []byte("package main\n"),
+		"app.log":             []byte("log line\n"),
+		"keep.log":            []byte("kept\n"),
+		"node_modules/lib.js": []byte("export {}\n"),
+		"large.txt":           []byte("0123456789abcdef"),
+		"blob.bin.txt":        {0x00, 0x01, 0x02},
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec, err := ignore.LoadSpec(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Root: root, IgnoreSpec: spec, MaxFileBytes: 15}
+
+	cases := []struct {
+		path      string
+		status    string
+		reason    string
+		pattern   string
+		directory string
+	}{
+		{"src/main.go", StatusCounted, "", "", ""},
+		{"app.log", StatusIgnored, ReasonPattern, "*.log", ""},
+		{"keep.log", StatusCounted, "", "!keep.log", ""},
+		{"node_modules/lib.js", StatusIgnored, ReasonPattern, "node_modules", "node_modules/"},
+		{"large.txt", StatusSkipped, ReasonOversized, "", ""},
+		{"blob.bin.txt", StatusSkipped, ReasonBinary, "", ""},
+	}
+	for _, tc := range cases {
+		exp, err := Explain(opts, filepath.Join(root, filepath.FromSlash(tc.path)))
+		if err != nil {
+			t.Fatalf("Explain(%s): %v", tc.path, err)
+		}
+		if exp.Status != tc.status || exp.Reason != tc.reason {
+			t.Errorf("Explain(%s) = %s/%s, want %s/%s", tc.path, exp.Status, exp.Reason, tc.status, tc.reason)
+		}
+		if tc.pattern != "" && (exp.Match == nil || exp.Match.Pattern.Text != tc.pattern) {
+			t.Errorf("Explain(%s) match = %+v, want pattern %q", tc.path, exp.Match, tc.pattern)
+		}
+		if exp.Directory != tc.directory {
+			t.Errorf("Explain(%s) directory = %q, want %q", tc.path, exp.Directory, tc.directory)
+		}
+	}
+
+	exp, err := Explain(opts, filepath.Join(root, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Match.Pattern.Source != filepath.Join(root, ".gitignore") || exp.Match.Pattern.Line != 1 {
+		t.Fatalf("expected .gitignore line 1, got %+v", exp.Match.Pattern)
+	}
+}
@@ -0,0 +1,210 @@
+package count
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Napageneral/tokcount/internal/cache"
+	"github.com/Napageneral/tokcount/internal/language"
+	"github.com/Napageneral/tokcount/internal/lexical"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+)
+
+type fileJob struct {
+	index   int
+	relPath string
+	size    int64
+	read    func() ([]byte, error)
+}
+
+type fileOutcome struct {
+	index      int
+	stat       FileStat
+	size       int64
+	binary     bool
+	unreadable bool
+	// vanished files were listed but deleted before they could be read.
+	vanished bool
+	// elided is set when the skeleton view elided at least one body.
+	elided bool
+	err    error
+}
+
+// workerPool reads and tokenizes files on a fixed number of goroutines.
+type workerPool struct {
+	jobs      chan fileJob
+	outcomes  chan fileOutcome
+	workers   sync.WaitGroup
+	collector sync.WaitGroup
+	collected []fileOutcome
+	next      int
+}
+
+// newWorkerPool counts every file with each of toks; the first one fills
+// FileStat.Tokens, and Counts is set when there is more than one. The first
+// also counts the skeleton view when skeleton is set.
+func newWorkerPool(size int, toks []tokenizer.Tokenizer, store *cache.Cache, skeleton skeletonFunc) *workerPool {
+	if size <= 0 {
+		size = 1
+	}
+	p := &workerPool{
+		jobs:     make(chan fileJob, size*4),
+		outcomes: make(chan fileOutcome, size*4),
+	}
+
+	p.collector.Add(1)
+	go func() {
+		defer p.collector.Done()
+		for outcome := range p.outcomes {
+			for len(p.collected) <= outcome.index {
+				p.collected = append(p.collected, fileOutcome{vanished: true})
+			}
+			p.collected[outcome.index] = outcome
+		}
+	}()
+
+	counters := make([]*cachedCounter, len(toks))
+	for i, tok := range toks {
+		counters[i] = &cachedCounter{tok: tok, store: store}
+		if store != nil {
+			counters[i].key = cache.TokenizerKey(tok.Name(), tok.Description())
+		}
+	}
+
+	p.workers.Add(size)
+	for i := 0; i < size; i++ {
+		go func() {
+			defer p.workers.Done()
+			for job := range p.jobs {
+				p.outcomes <- processFile(job, counters, skeleton)
+			}
+		}()
+	}
+	return p
+}
+
+// submit queues a file; jobs are indexed in submission order.
+func (p *workerPool) submit(relPath string, size int64, read func() ([]byte, error)) {
+	p.jobs <- fileJob{index: p.next, relPath: relPath, size: size, read: read}
+	p.next++
+}
+
+// wait closes the pool and returns outcomes ordered by job index.
+func (p *workerPool) wait() []fileOutcome {
+	close(p.jobs)
+	p.workers.Wait()
+	close(p.outcomes)
+	p.collector.Wait()
+	return p.collected
+}
+
+// skeletonFunc is the type of Options.Skeleton.
+type skeletonFunc = func(language string, content []byte) ([]byte, bool)
+
+func processFile(job fileJob, counters []*cachedCounter, skeleton skeletonFunc) fileOutcome {
+	outcome := fileOutcome{index: job.index, size: job.size}
+
+	data, err := job.read()
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			outcome.vanished = true
+		case errors.Is(err, os.ErrPermission):
+			outcome.unreadable = true
+		default:
+			outcome.err = err
+		}
+		return outcome
+	}
+
+	if isLikelyBinary(data) {
+		outcome.binary = true
+		outcome.size = int64(len(data))
+		return outcome
+	}
+
+	var hash string
+	outcome.stat = FileStat{
+		Path:      filepath.ToSlash(job.relPath),
+		Tokens:    counters[0].count(job.relPath, data, &hash),
+		Bytes:     int64(len(data)),
+		Lines:     countLines(data),
+		Extension: strings.ToLower(filepath.Ext(job.relPath)),
+		Language:  language.Detect(job.relPath, data),
+	}
+	lex := lexical.Classify(outcome.stat.Language, data)
+	outcome.stat.LineSplit = Split{
+		Code:      lex.CodeLines,
+		Comment:   lex.CommentLines,
+		Docstring: lex.DocstringLines,
+		Blank:     lex.BlankLines,
+	}
+	outcome.stat.TokenSplit = splitTokens(counters[0].tok, job.relPath, outcome.stat.Tokens, lex)
+	if skeleton != nil {
+		outcome.stat.SkeletonTokens = outcome.stat.Tokens
+		// Tokenized directly, like the split fragments, to keep the cache at
+		// one entry per file.
+		if view, ok := skeleton(outcome.stat.Language, data); ok {
+			outcome.stat.SkeletonTokens = tokenizer.CountFile(counters[0].tok, job.relPath, string(view))
+			outcome.elided = true
+		}
+	}
+	if len(counters) > 1 {
+		outcome.stat.Counts = map[string]int{tokenizer.Label(counters[0].tok): outcome.stat.Tokens}
+		for _, counter := range counters[1:] {
+			outcome.stat.Counts[tokenizer.Label(counter.tok)] = counter.count(job.relPath, data, &hash)
+		}
+	}
+	return outcome
+}
+
+// splitTokens counts the comments, docstrings and blank lines of a file on
+// their own; code is the rest of the file's tokens. The fragments are
+// tokenized directly: caching them would add up to three entries per file.
+func splitTokens(tok tokenizer.Tokenizer, relPath string, total int, lex lexical.Split) Split {
+	split := Split{
+		Comment:   tokenizer.CountFile(tok, relPath, lex.Comment),
+		Docstring: tokenizer.CountFile(tok, relPath, lex.Docstring),
+		Blank:     tokenizer.CountFile(tok, relPath, lex.Blank),
+	}
+	rest := split.Comment + split.Docstring + split.Blank
+	if rest <= total {
+		split.Code = total - rest
+		return split
+	}
+	// Tokens can merge across the cuts, so the parts may outnumber the
+	// whole; scale them down to keep the split summing to total.
+	split.Comment = split.Comment * total / rest
+	split.Docstring = split.Docstring * total / rest
+	split.Blank = total - split.Comment - split.Docstring
+	return split
+}
+
+// cachedCounter consults the content cache before tokenizing.
+type cachedCounter struct {
+	tok   tokenizer.Tokenizer
+	store *cache.Cache
+	key   string
+}
+
+// count tokenizes data, or reads its count from the cache. hash carries the
+// content hash between the counters of one file so it is computed once.
+func (c *cachedCounter) count(relPath string, data []byte, hash *string) int {
+	// Path-dependent tokenizers are cheap estimates; hashing and a disk
+	// lookup would cost more than counting, and the key would need the path.
+	if _, ok := c.tok.(tokenizer.PathCounter); ok || c.store == nil {
+		return tokenizer.CountFile(c.tok, relPath, string(data))
+	}
+	if *hash == "" {
+		*hash = cache.HashContent(data)
+	}
+	if tokens, ok := c.store.Get(c.key, *hash); ok {
+		return tokens
+	}
+	tokens := c.tok.Count(string(data))
+	_ = c.store.Put(c.key, *hash, tokens)
+	return tokens
+}
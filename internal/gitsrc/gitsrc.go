@@ -0,0 +1,363 @@
+package gitsrc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+// Tree entry modes of submodules and symlinks; neither is a file to count,
+// matching the filesystem walk.
+const (
+	gitlinkMode = "160000"
+	symlinkMode = "120000"
+)
+
+// TrackedSource yields files in the git index, read from the working tree.
+type TrackedSource struct {
+	root    string
+	entries []count.SourceEntry
+}
+
+// NewTracked lists the files tracked in the index under root.
+func NewTracked(root string) (*TrackedSource, error) {
+	out, err := runGit(root, "ls-files", "-z", "--stage")
+	if err != nil {
+		return nil, fmt.Errorf("list tracked files: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	entries := make([]count.SourceEntry, 0)
+	for _, record := range splitNul(out) {
+		// <mode> SP <object> SP <stage> TAB <path>
+		meta, path, ok := strings.Cut(record, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) < 3 || fields[0] == gitlinkMode || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		entries = append(entries, count.SourceEntry{RelPath: path, Size: info.Size()})
+	}
+	return &TrackedSource{root: root, entries: entries}, nil
+}
+
+func (s *TrackedSource) Walk(fn func(entry count.SourceEntry) error) error {
+	for _, entry := range s.entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TrackedSource) Read(relPath string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.root, filepath.FromSlash(relPath)))
+}
+
+func (s *TrackedSource) Close() error {
+	return nil
+}
+
+// RevisionSource yields the files of a tree-ish, read from the object store.
+type RevisionSource struct {
+	rev string
+	// root is the absolute listing root and prefix its path from the top of
+	// the work tree, for ReadTreeFile.
+	root    string
+	prefix  string
+	entries []count.SourceEntry
+	objects map[string]string
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	out   *bufio.Reader
+}
+
+// NewRevision lists the blobs of rev under root without touching the
+// working copy. Call Close to stop the backing git process.
+func NewRevision(root string, rev string) (*RevisionSource, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		return nil, fmt.Errorf("revision is required")
+	}
+	if _, err := runGit(root, "rev-parse", "--verify", "--quiet", rev+"^{tree}"); err != nil {
+		return nil, fmt.Errorf("resolve revision %s: %w", rev, err)
+	}
+
+	out, err := runGit(root, "ls-tree", "-r", "-z", "-l", rev, "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("list revision %s: %w", rev, err)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve root path: %w", err)
+	}
+	prefix, err := runGit(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("locate %s in its work tree: %w", root, err)
+	}
+
+	src := &RevisionSource{
+		rev:     rev,
+		root:    absRoot,
+		prefix:  strings.TrimSpace(string(prefix)),
+		objects: make(map[string]string),
+	}
+	for _, record := range splitNul(out) {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		meta, path, ok := strings.Cut(record, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) < 4 || fields[1] != "blob" || fields[0] == symlinkMode || fields[0] == gitlinkMode {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		src.entries = append(src.entries, count.SourceEntry{RelPath: path, Size: size})
+		src.objects[path] = fields[2]
+	}
+
+	cmd := exec.Command("git", "-C", root, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start git cat-file: %w", err)
+	}
+	src.cmd = cmd
+	src.stdin = stdin
+	src.out = bufio.NewReader(stdout)
+	return src, nil
+}
+
+func (s *RevisionSource) Revision() string {
+	return s.rev
+}
+
+func (s *RevisionSource) Walk(fn func(entry count.SourceEntry) error) error {
+	for _, entry := range s.entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read returns a blob's content. Reads are serialized over a single
+// git cat-file process.
+func (s *RevisionSource) Read(relPath string) ([]byte, error) {
+	oid, ok := s.objects[relPath]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", relPath, os.ErrNotExist)
+	}
+	return s.readObject(oid, relPath)
+}
+
+// ReadTreeFile reads a file of the revision by absolute path. Unlike Read
+// it reaches files outside the listing, such as the .gitignore of a parent
+// directory, as long as they are inside the work tree.
+func (s *RevisionSource) ReadTreeFile(absPath string) ([]byte, error) {
+	rel, err := filepath.Rel(s.root, absPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", absPath, os.ErrNotExist)
+	}
+	treePath := path.Join(s.prefix, filepath.ToSlash(rel))
+	if treePath == ".." || strings.HasPrefix(treePath, "../") {
+		return nil, fmt.Errorf("%s: %w", absPath, os.ErrNotExist)
+	}
+	return s.readObject(s.rev+":"+treePath, absPath)
+}
+
+// readObject returns the content of a blob named by object id or
+// "<rev>:<path>". Reads are serialized over the git cat-file process.
+func (s *RevisionSource) readObject(name string, label string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := fmt.Fprintln(s.stdin, name); err != nil {
+		return nil, fmt.Errorf("request blob %s: %w", label, err)
+	}
+	header, err := s.out.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read blob header %s: %w", label, err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 2 && fields[1] == "missing" {
+		return nil, fmt.Errorf("%s: %w", label, os.ErrNotExist)
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("read blob %s: unexpected header %q", label, strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("read blob %s: %w", label, err)
+	}
+
+	data := make([]byte, size+1) // content plus trailing LF
+	if _, err := io.ReadFull(s.out, data); err != nil {
+		return nil, fmt.Errorf("read blob %s: %w", label, err)
+	}
+	if fields[1] != "blob" {
+		return nil, fmt.Errorf("%s: %w", label, os.ErrNotExist)
+	}
+	return data[:size], nil
+}
+
+func (s *RevisionSource) Close() error {
+	if s.cmd == nil {
+		return nil
+	}
+	s.stdin.Close()
+	err := s.cmd.Wait()
+	s.cmd = nil
+	return err
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func splitNul(out []byte) []string {
+	parts := strings.Split(string(out), "\x00")
+	records := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			records = append(records, part)
+		}
+	}
+	return records
+}
+
+// LastChanged returns when each of paths last changed: the time of its
+// newest commit, or its modification time when it has uncommitted changes.
+// Untracked paths are left out. Paths are slash-separated and relative to
+// root. The log is read newest first and stops once every tracked path has
+// a time, so recent files do not cost a walk of the whole history.
+func LastChanged(root string, paths []string) (map[string]time.Time, error) {
+	changed := make(map[string]time.Time)
+	dirty, err := runGit(root, "diff", "--relative", "--name-only", "-z", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("list uncommitted changes: %w", err)
+	}
+	for _, path := range splitNul(dirty) {
+		if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(path))); err == nil {
+			changed[path] = info.ModTime()
+		}
+	}
+
+	tracked, err := runGit(root, "ls-files", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("list tracked files: %w", err)
+	}
+	isTracked := make(map[string]bool)
+	for _, path := range splitNul(tracked) {
+		isTracked[path] = true
+	}
+	pending := make(map[string]bool)
+	for _, path := range paths {
+		if _, done := changed[path]; isTracked[path] && !done {
+			pending[path] = true
+		}
+	}
+	if len(pending) == 0 {
+		return changed, nil
+	}
+
+	// Each commit is a NUL, its time and a NUL, then its paths, each ended
+	// by a NUL; the first path follows a newline.
+	cmd := exec.Command("git", "-C", root, "log", "--relative", "--no-renames", "--name-only", "-z", "--format=%x00%ct")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("read commit times: %w", err)
+	}
+	out := bufio.NewReader(stdout)
+	var commitTime time.Time
+	header, first := false, false
+	for len(pending) > 0 {
+		record, err := out.ReadString(0)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return nil, fmt.Errorf("read commit times: %w", err)
+		}
+		record = strings.TrimSuffix(record, "\x00")
+		switch {
+		case record == "":
+			header = true
+		case header:
+			seconds, err := strconv.ParseInt(record, 10, 64)
+			if err != nil {
+				cmd.Process.Kill()
+				cmd.Wait()
+				return nil, fmt.Errorf("read commit times: bad timestamp %q", record)
+			}
+			commitTime = time.Unix(seconds, 0)
+			header, first = false, true
+		default:
+			if first {
+				record = strings.TrimPrefix(record, "\n")
+				first = false
+			}
+			// The log is newest first, so the first time seen is the latest.
+			if pending[record] {
+				changed[record] = commitTime
+				delete(pending, record)
+			}
+		}
+	}
+	if len(pending) > 0 {
+		if err := cmd.Wait(); err != nil {
+			return nil, fmt.Errorf("read commit times: %w", err)
+		}
+		return changed, nil
+	}
+	// Every path has a time; the rest of the history is not needed.
+	cmd.Process.Kill()
+	cmd.Wait()
+	return changed, nil
+}
@@ -0,0 +1,213 @@
+package gitsrc
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/ignore"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel string, content string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("src/main.go", "package main\n\nfunc main() {}\n")
+	write("README.md", "# demo\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	git("tag", "v1")
+
+	write("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"changed after tag\")\n}\n")
+	write("untracked.txt", "not in the index\n")
+	return root
+}
+
+func TestTrackedSource_SkipsUntrackedFiles(t *testing.T) {
+	root := initRepo(t)
+
+	src, err := NewTracked(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := count.Run(count.Options{Root: root, Tokenizer: tokenizer.NewEstimate(3.5), Source: src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalFiles != 2 {
+		t.Fatalf("expected 2 tracked files, got %d: %+v", result.TotalFiles, result.Files)
+	}
+}
+
+func TestRevisionSource_ReadsBlobsAtRevision(t *testing.T) {
+	root := initRepo(t)
+
+	src, err := NewRevision(root, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	data, err := src.Read("src/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package main\n\nfunc main() {}\n" {
+		t.Fatalf("expected tagged content, got %q", data)
+	}
+
+	result, err := count.Run(count.Options{Root: root, Tokenizer: tokenizer.NewEstimate(3.5), Source: src, Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalFiles != 2 {
+		t.Fatalf("expected 2 files at v1, got %d", result.TotalFiles)
+	}
+}
+
+func TestRevisionSource_IgnoreFilesAtRevision(t *testing.T) {
+	root := initRepo(t)
+	// The working copy ignores src/; the tree tagged v1 has no .gitignore.
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("src/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewRevision(root, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	spec, err := ignore.LoadSpecWithOptions(root, ignore.Options{ReadFile: src.ReadTreeFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := count.Run(count.Options{Root: root, Tokenizer: tokenizer.NewEstimate(3.5), IgnoreSpec: spec, Source: src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalFiles != 2 {
+		t.Fatalf("expected the v1 ignore rules to keep both files, got %+v", result.Files)
+	}
+
+	sub, err := NewRevision(filepath.Join(root, "src"), "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	data, err := sub.ReadTreeFile(filepath.Join(root, "README.md"))
+	if err != nil || string(data) != "# demo\n" {
+		t.Fatalf("expected to read a file above the listing root, got %q, %v", data, err)
+	}
+	if _, err := sub.ReadTreeFile(filepath.Join(root, ".gitignore")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a file missing at v1 to be reported as not existing, got %v", err)
+	}
+}
+
+func TestRevisionSource_SkipsSymlinksLikeTracked(t *testing.T) {
+	root := initRepo(t)
+	if err := os.Symlink("README.md", filepath.Join(root, "link.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "link"}} {
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	paths := func(src count.Source) []string {
+		var out []string
+		src.Walk(func(entry count.SourceEntry) error {
+			out = append(out, entry.RelPath)
+			return nil
+		})
+		sort.Strings(out)
+		return out
+	}
+	tracked, err := NewTracked(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev, err := NewRevision(root, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rev.Close()
+	if got, want := paths(rev), paths(tracked); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected HEAD to list the tracked files %v, got %v", want, got)
+	}
+}
+
+func TestLastChanged_ReadsCommitTimesOfOddPaths(t *testing.T) {
+	root := initRepo(t)
+	odd := "tab\tand \"quote\".txt"
+	if err := os.WriteFile(filepath.Join(root, odd), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", odd}, {"commit", "-q", "-m", "odd"}} {
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2030-01-02T03:04:05Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	changed, err := LastChanged(root, []string{odd, "src/main.go", "untracked.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC); !changed[odd].Equal(want) {
+		t.Fatalf("expected %q to have its commit time %v, got %v", odd, want, changed[odd])
+	}
+	info, err := os.Stat(filepath.Join(root, "src", "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed["src/main.go"].Equal(info.ModTime()) {
+		t.Fatalf("expected the modified src/main.go to use its modification time, got %v", changed["src/main.go"])
+	}
+	if _, ok := changed["untracked.txt"]; ok {
+		t.Fatalf("expected untracked.txt to be left out")
+	}
+	if _, ok := changed["README.md"]; ok {
+		t.Fatalf("expected only the requested paths, got %v", changed)
+	}
+}
+
+func TestNewRevision_UnknownRevision(t *testing.T) {
+	root := initRepo(t)
+
+	if _, err := NewRevision(root, "does-not-exist"); err == nil {
+		t.Fatalf("expected error for unknown revision")
+	}
+}
@@ -0,0 +1,100 @@
+package ignore
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// repository locates the pieces of a git work tree that carry ignore rules.
+type repository struct {
+	workTree  string
+	gitDir    string
+	commonDir string
+}
+
+// findRepository walks up from dir to the nearest .git directory or file.
+func findRepository(dir string) (repository, bool) {
+	for current := dir; ; {
+		dotGit := filepath.Join(current, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			gitDir := dotGit
+			if !info.IsDir() {
+				gitDir = readGitDirFile(dotGit, current)
+			}
+			if gitDir != "" {
+				return repository{
+					workTree:  current,
+					gitDir:    gitDir,
+					commonDir: resolveCommonDir(gitDir),
+				}, true
+			}
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return repository{}, false
+		}
+		current = parent
+	}
+}
+
+// WorkTree returns the top of the git work tree holding dir, if any.
+func WorkTree(dir string) (string, bool) {
+	repo, ok := findRepository(dir)
+	return repo.workTree, ok
+}
+
+// readGitDirFile resolves a "gitdir: <path>" file used by worktrees and
+// submodules.
+func readGitDirFile(path string, workTree string) string {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	value, ok := strings.CutPrefix(strings.TrimSpace(string(raw)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	value = strings.TrimSpace(value)
+	if !filepath.IsAbs(value) {
+		value = filepath.Join(workTree, value)
+	}
+	return filepath.Clean(value)
+}
+
+// resolveCommonDir follows a linked worktree's commondir file, where
+// info/exclude lives.
+func resolveCommonDir(gitDir string) string {
+	raw, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	common := strings.TrimSpace(string(raw))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return filepath.Clean(common)
+}
+
+// excludesFile returns core.excludesFile from git config, or git's default
+// of $XDG_CONFIG_HOME/git/ignore when it is unset.
+func (r repository) excludesFile() string {
+	out, err := exec.Command("git", "-C", r.workTree, "config", "--path", "--get", "core.excludesFile").Output()
+	if err == nil {
+		if path := strings.TrimSpace(string(out)); path != "" {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(r.workTree, path)
+			}
+			return path
+		}
+	}
+
+	if xdg := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "git", "ignore")
+	}
+	return ""
+}
@@ -2,68 +2,175 @@ package ignore
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	gitignore "github.com/sabhiram/go-gitignore"
 )
 
 // Spec encapsulates ignore matching rooted at a repository path.
+//
+// Patterns are evaluated the way git does: every layer is scoped to the
+// directory it was loaded from, later layers take precedence over earlier
+// ones, and within the whole sequence the last matching pattern wins, so
+// a "!pattern" re-includes a path excluded before it.
 type Spec struct {
 	root     string
-	matcher  *gitignore.GitIgnore
+	repoRoot string
+	before   []*layer
+	after    []*layer
 	patterns []string
+	includes *gitignore.GitIgnore
+	readFile func(path string) ([]byte, error)
+
+	mu        sync.Mutex
+	dirLayers map[string]*layer
+}
+
+// layer is the compiled content of one ignore source.
+type layer struct {
+	base  string
+	rules []rule
+}
+
+// rule is a single compiled pattern. Negated patterns are compiled without
+// their leading "!" so the match itself can be observed.
+type rule struct {
+	pattern Pattern
+	negate  bool
+	matcher *gitignore.GitIgnore
+}
+
+// Source labels for patterns that do not come from a file.
+const (
+	SourceDefault = "<default>"
+	SourceConfig  = "<config>"
+)
+
+// Pattern is an ignore pattern and where it was declared.
+type Pattern struct {
+	Text   string `json:"pattern"`
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+}
+
+// Match explains which rule decided a path.
+type Match struct {
+	// Pattern is the last pattern that matched. A negated pattern means
+	// the path was re-included.
+	Pattern Pattern `json:"pattern"`
+	Negated bool    `json:"negated"`
+	// NotIncluded is set when the path matched no include pattern.
+	NotIncluded bool `json:"not_included,omitempty"`
+}
+
+// Options extends LoadSpec with patterns supplied outside ignore files,
+// such as those from a project config.
+type Options struct {
+	CustomIgnoreFile string
+	// ExtraPatterns are applied after every ignore file.
+	ExtraPatterns []string
+	// IncludePatterns, when non-empty, restrict counting to files that
+	// match at least one pattern. Directories are never excluded by them.
+	IncludePatterns []string
+	// ReadFile, when set, reads the ignore files of the tree being scanned
+	// (.gitignore, .cartographerignore, and a relative CustomIgnoreFile) by
+	// absolute path instead of the working copy, e.g. from a git revision.
+	// It must return an error wrapping fs.ErrNotExist for missing files.
+	ReadFile func(path string) ([]byte, error)
 }
 
 // LoadSpec compiles default patterns + .cartographerignore + .gitignore
 // and optionally a custom ignore file path.
 func LoadSpec(scopeRoot string, customIgnoreFile string) (*Spec, error) {
+	return LoadSpecWithOptions(scopeRoot, Options{CustomIgnoreFile: customIgnoreFile})
+}
+
+// LoadSpecWithOptions is LoadSpec with extra and include patterns.
+//
+// Layers, lowest precedence first:
+//  1. built-in defaults
+//  2. core.excludesFile (when root is inside a git work tree)
+//  3. .git/info/exclude
+//  4. .cartographerignore in root
+//  5. .gitignore in each directory from the work tree top down to the
+//     matched path's parent, loaded lazily
+//  6. the custom ignore file
+//  7. extra patterns from Options
+//
+// With Options.ReadFile, layers 4 to 6 are read from that tree; the
+// excludes files are local settings and always come from disk.
+func LoadSpecWithOptions(scopeRoot string, opts Options) (*Spec, error) {
 	root, err := filepath.Abs(scopeRoot)
 	if err != nil {
 		return nil, fmt.Errorf("resolve root path: %w", err)
 	}
 
-	patterns := DefaultPatterns()
+	spec := &Spec{
+		root:      root,
+		repoRoot:  root,
+		readFile:  opts.ReadFile,
+		dirLayers: make(map[string]*layer),
+	}
+	spec.addBefore(root, patternsFrom(SourceDefault, DefaultPatterns()))
+
+	if repo, ok := findRepository(root); ok {
+		spec.repoRoot = repo.workTree
+		if path := repo.excludesFile(); path != "" {
+			p, err := readIgnoreFileOptional(path)
+			if err != nil {
+				return nil, fmt.Errorf("load core.excludesFile: %w", err)
+			}
+			spec.addBefore(repo.workTree, p)
+		}
+		p, err := readIgnoreFileOptional(filepath.Join(repo.commonDir, "info", "exclude"))
+		if err != nil {
+			return nil, fmt.Errorf("load info/exclude: %w", err)
+		}
+		spec.addBefore(repo.workTree, p)
+	}
 
-	if p, err := readIgnoreFileOptional(filepath.Join(root, ".cartographerignore")); err != nil {
+	if p, err := spec.readTreeFileOptional(filepath.Join(root, ".cartographerignore")); err != nil {
 		return nil, err
 	} else {
-		patterns = append(patterns, p...)
+		spec.addBefore(root, p)
 	}
 
-	if p, err := readIgnoreFileOptional(filepath.Join(root, ".gitignore")); err != nil {
+	// Fail early on an unreadable root .gitignore; nested ones are
+	// best-effort.
+	if _, err := spec.readTreeFileOptional(filepath.Join(root, ".gitignore")); err != nil {
 		return nil, err
-	} else {
-		patterns = append(patterns, p...)
 	}
 
-	if strings.TrimSpace(customIgnoreFile) != "" {
-		customPath := customIgnoreFile
-		if !filepath.IsAbs(customPath) {
-			customPath = filepath.Join(root, customPath)
+	if strings.TrimSpace(opts.CustomIgnoreFile) != "" {
+		// A relative custom ignore file is part of the scanned tree.
+		var p []Pattern
+		var err error
+		if customPath := opts.CustomIgnoreFile; filepath.IsAbs(customPath) {
+			p, err = readIgnoreFileRequired(customPath)
+		} else {
+			p, err = spec.readTreeFile(filepath.Join(root, customPath))
 		}
-		p, err := readIgnoreFileRequired(customPath)
 		if err != nil {
 			return nil, fmt.Errorf("load custom ignore file: %w", err)
 		}
-		patterns = append(patterns, p...)
+		spec.addAfter(root, p)
 	}
 
-	patterns = dedupePatterns(patterns)
+	spec.addAfter(root, patternsFrom(SourceConfig, opts.ExtraPatterns))
 
-	var matcher *gitignore.GitIgnore
-	if len(patterns) > 0 {
-		matcher = gitignore.CompileIgnoreLines(patterns...)
+	if includePatterns := dedupePatterns(opts.IncludePatterns); len(includePatterns) > 0 {
+		spec.includes = gitignore.CompileIgnoreLines(includePatterns...)
 	}
 
-	return &Spec{
-		root:     root,
-		matcher:  matcher,
-		patterns: patterns,
-	}, nil
+	return spec, nil
 }
 
 func (s *Spec) Root() string {
@@ -73,6 +180,8 @@ func (s *Spec) Root() string {
 	return s.root
 }
 
+// Patterns returns the eagerly loaded patterns in precedence order.
+// Nested .gitignore files are loaded on demand and are not included.
 func (s *Spec) Patterns() []string {
 	if s == nil {
 		return nil
@@ -84,8 +193,16 @@ func (s *Spec) Patterns() []string {
 
 // MatchPath reports whether an absolute path should be ignored.
 func (s *Spec) MatchPath(absPath string, isDir bool) bool {
-	if s == nil || s.matcher == nil {
-		return false
+	ignored, _ := s.MatchPathHow(absPath, isDir)
+	return ignored
+}
+
+// MatchPathHow reports whether an absolute path should be ignored and the
+// rule that decided it. The match is nil when no pattern applied. Parent
+// directories are not consulted; callers walking a tree prune those first.
+func (s *Spec) MatchPathHow(absPath string, isDir bool) (bool, *Match) {
+	if s == nil {
+		return false, nil
 	}
 	rel, err := filepath.Rel(s.root, absPath)
 	if err != nil {
@@ -93,17 +210,178 @@ func (s *Spec) MatchPath(absPath string, isDir bool) bool {
 	}
 	rel = filepath.Clean(rel)
 	if rel == "." || rel == "" {
-		return false
+		return false, nil
+	}
+
+	ignored := false
+	var match *Match
+	for _, l := range s.layersFor(absPath) {
+		layerRel, ok := l.relative(absPath, isDir)
+		if !ok {
+			continue
+		}
+		for _, r := range l.rules {
+			if r.matcher.MatchesPath(layerRel) {
+				ignored = !r.negate
+				match = &Match{Pattern: r.pattern, Negated: r.negate}
+			}
+		}
+	}
+	if ignored {
+		return true, match
 	}
 
 	rel = filepath.ToSlash(rel)
+	if !isDir && s.includes != nil && !s.includes.MatchesPath(rel) {
+		return true, &Match{NotIncluded: true}
+	}
+	return false, match
+}
+
+// layersFor returns every layer that applies to absPath in precedence order.
+func (s *Spec) layersFor(absPath string) []*layer {
+	layers := make([]*layer, 0, len(s.before)+len(s.after)+4)
+	layers = append(layers, s.before...)
+
+	dirs := make([]string, 0)
+	for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+		if !isWithin(s.repoRoot, dir) {
+			break
+		}
+		dirs = append(dirs, dir)
+		if dir == s.repoRoot {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if l := s.dirLayer(dirs[i]); l != nil {
+			layers = append(layers, l)
+		}
+	}
+
+	return append(layers, s.after...)
+}
+
+// dirLayer loads and caches the .gitignore of a single directory.
+func (s *Spec) dirLayer(dir string) *layer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.dirLayers[dir]; ok {
+		return l
+	}
+	var l *layer
+	if p, err := s.readTreeFileOptional(filepath.Join(dir, ".gitignore")); err == nil && len(p) > 0 {
+		l = compileLayer(dir, p)
+	}
+	s.dirLayers[dir] = l
+	return l
+}
+
+func (s *Spec) addBefore(base string, patterns []Pattern) {
+	if len(patterns) == 0 {
+		return
+	}
+	s.before = append(s.before, compileLayer(base, patterns))
+	for _, p := range patterns {
+		s.patterns = append(s.patterns, p.Text)
+	}
+}
+
+func (s *Spec) addAfter(base string, patterns []Pattern) {
+	if len(patterns) == 0 {
+		return
+	}
+	s.after = append(s.after, compileLayer(base, patterns))
+	for _, p := range patterns {
+		s.patterns = append(s.patterns, p.Text)
+	}
+}
+
+func compileLayer(base string, patterns []Pattern) *layer {
+	l := &layer{base: base}
+	for _, p := range patterns {
+		text := strings.TrimSpace(p.Text)
+		negate := strings.HasPrefix(text, "!")
+		if negate {
+			text = text[1:]
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		l.rules = append(l.rules, rule{
+			pattern: p,
+			negate:  negate,
+			matcher: gitignore.CompileIgnoreLines(text),
+		})
+	}
+	return l
+}
+
+// patternsFrom labels in-memory patterns with a source and 1-based index.
+func patternsFrom(source string, lines []string) []Pattern {
+	out := make([]Pattern, 0, len(lines))
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		out = append(out, Pattern{Text: line, Source: source, Line: i + 1})
+	}
+	return out
+}
+
+// relative returns absPath relative to the layer base in slash form, with a
+// trailing slash for directories.
+func (l *layer) relative(absPath string, isDir bool) (string, bool) {
+	rel, err := filepath.Rel(l.base, absPath)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
 	if isDir && !strings.HasSuffix(rel, "/") {
 		rel += "/"
 	}
-	return s.matcher.MatchesPath(rel)
+	return rel, true
+}
+
+func isWithin(base string, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
 }
 
-func readIgnoreFileOptional(path string) ([]string, error) {
+// readTreeFile reads an ignore file of the scanned tree, through ReadFile
+// when one was given.
+func (s *Spec) readTreeFile(path string) ([]Pattern, error) {
+	if s.readFile == nil {
+		return readIgnoreFile(path)
+	}
+	data, err := s.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseIgnoreFile(path, bytes.NewReader(data))
+}
+
+// readTreeFileOptional is readTreeFile that treats a missing file as empty.
+func (s *Spec) readTreeFileOptional(path string) ([]Pattern, error) {
+	if s.readFile == nil {
+		return readIgnoreFileOptional(path)
+	}
+	p, err := s.readTreeFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return p, err
+}
+
+func readIgnoreFileOptional(path string) ([]Pattern, error) {
 	_, err := os.Stat(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -114,20 +392,26 @@ func readIgnoreFileOptional(path string) ([]string, error) {
 	return readIgnoreFile(path)
 }
 
-func readIgnoreFileRequired(path string) ([]string, error) {
+func readIgnoreFileRequired(path string) ([]Pattern, error) {
 	return readIgnoreFile(path)
 }
 
-func readIgnoreFile(path string) ([]string, error) {
+func readIgnoreFile(path string) ([]Pattern, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return parseIgnoreFile(path, f)
+}
 
-	out := make([]string, 0)
-	scanner := bufio.NewScanner(f)
+// parseIgnoreFile reads gitignore-syntax patterns, labelled with path.
+func parseIgnoreFile(path string, r io.Reader) ([]Pattern, error) {
+	out := make([]Pattern, 0)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
@@ -135,7 +419,7 @@ func readIgnoreFile(path string) ([]string, error) {
 		if strings.HasPrefix(line, "#") {
 			continue
 		}
-		out = append(out, line)
+		out = append(out, Pattern{Text: line, Source: path, Line: lineNo})
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
@@ -37,3 +37,83 @@ func TestLoadSpec_WithDefaultAndCustomPatterns(t *testing.T) {
 		t.Fatalf("did not expect kept.txt to be ignored")
 	}
 }
+
+func TestLoadSpecWithOptions_ExtraAndIncludePatterns(t *testing.T) {
+	root := t.TempDir()
+
+	spec, err := LoadSpecWithOptions(root, Options{
+		ExtraPatterns:   []string{"fixtures/"},
+		IncludePatterns: []string{"*.go", "docs/**"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !spec.MatchPath(filepath.Join(root, "fixtures"), true) {
+		t.Fatalf("expected extra pattern to match fixtures/")
+	}
+	if spec.MatchPath(filepath.Join(root, "src", "main.go"), false) {
+		t.Fatalf("did not expect included main.go to be ignored")
+	}
+	if spec.MatchPath(filepath.Join(root, "docs", "guide.md"), false) {
+		t.Fatalf("did not expect included docs/guide.md to be ignored")
+	}
+	if !spec.MatchPath(filepath.Join(root, "src", "notes.txt"), false) {
+		t.Fatalf("expected notes.txt outside include patterns to be ignored")
+	}
+	if spec.MatchPath(filepath.Join(root, "src"), true) {
+		t.Fatalf("did not expect include patterns to prune directories")
+	}
+}
+
+func TestLoadSpec_HierarchicalGitIgnore(t *testing.T) {
+	root := t.TempDir()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(configHome, "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	write := func(path string, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(configHome, "git", "ignore"), "*.swp\n")
+	write(filepath.Join(root, ".git", "info", "exclude"), "scratch/\n")
+	write(filepath.Join(root, ".gitignore"), "*.log\n")
+	write(filepath.Join(root, "pkg", ".gitignore"), "generated.go\n!keep.log\n")
+	write(filepath.Join(root, "pkg", "sub", ".gitignore"), "*.tmp\n")
+
+	spec, err := LoadSpec(root, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"editor.swp", false, true},
+		{"scratch", true, true},
+		{"app.log", false, true},
+		{"pkg/generated.go", false, true},
+		{"generated.go", false, false},
+		{"pkg/keep.log", false, false},
+		{"pkg/other.log", false, true},
+		{"pkg/sub/cache.tmp", false, true},
+		{"pkg/cache.tmp", false, false},
+		{"pkg/sub/keep.log", false, false},
+	}
+	for _, tc := range cases {
+		got := spec.MatchPath(filepath.Join(root, filepath.FromSlash(tc.path)), tc.isDir)
+		if got != tc.ignored {
+			t.Errorf("MatchPath(%s) = %v, want %v", tc.path, got, tc.ignored)
+		}
+	}
+}
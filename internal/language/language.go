@@ -0,0 +1,227 @@
+// Package language classifies files by programming language from their
+// name, extension, and shebang, in the style of GitHub linguist.
+package language
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Other is the language of files that match no rule.
+const Other = "Other"
+
+// filenames match whole base names before any extension rule.
+var filenames = map[string]string{
+	"makefile":         "Makefile",
+	"gnumakefile":      "Makefile",
+	"dockerfile":       "Dockerfile",
+	"containerfile":    "Dockerfile",
+	"go.mod":           "Go Module",
+	"go.sum":           "Go Checksums",
+	"go.work":          "Go Workspace",
+	"gemfile":          "Ruby",
+	"rakefile":         "Ruby",
+	"vagrantfile":      "Ruby",
+	"podfile":          "Ruby",
+	"cmakelists.txt":   "CMake",
+	"jenkinsfile":      "Groovy",
+	"build.bazel":      "Starlark",
+	"cargo.lock":       "TOML",
+	"pipfile":          "TOML",
+	".gitignore":       "Ignore List",
+	".dockerignore":    "Ignore List",
+	".npmignore":       "Ignore List",
+	".gitattributes":   "Git Attributes",
+	".gitmodules":      "Git Config",
+	".editorconfig":    "EditorConfig",
+	".bashrc":          "Shell",
+	".bash_profile":    "Shell",
+	".zshrc":           "Shell",
+	".profile":         "Shell",
+	"license":          "Text",
+	"copying":          "Text",
+	"readme":           "Text",
+	"requirements.txt": "Pip Requirements",
+}
+
+// exactFilenames match case-sensitively, for names too common in lower case
+// ("build" scripts) to claim outright.
+var exactFilenames = map[string]string{
+	"BUILD":     "Starlark",
+	"WORKSPACE": "Starlark",
+}
+
+// extensions map lowercased extensions, including the dot.
+var extensions = map[string]string{
+	".go":         "Go",
+	".py":         "Python",
+	".pyi":        "Python",
+	".pyw":        "Python",
+	".js":         "JavaScript",
+	".mjs":        "JavaScript",
+	".cjs":        "JavaScript",
+	".jsx":        "JavaScript",
+	".ts":         "TypeScript",
+	".mts":        "TypeScript",
+	".cts":        "TypeScript",
+	".tsx":        "TSX",
+	".java":       "Java",
+	".kt":         "Kotlin",
+	".kts":        "Kotlin",
+	".scala":      "Scala",
+	".groovy":     "Groovy",
+	".gradle":     "Gradle",
+	".rs":         "Rust",
+	".c":          "C",
+	".h":          "C",
+	".cc":         "C++",
+	".cpp":        "C++",
+	".cxx":        "C++",
+	".hh":         "C++",
+	".hpp":        "C++",
+	".hxx":        "C++",
+	".cs":         "C#",
+	".fs":         "F#",
+	".swift":      "Swift",
+	".m":          "Objective-C",
+	".mm":         "Objective-C++",
+	".rb":         "Ruby",
+	".php":        "PHP",
+	".pl":         "Perl",
+	".pm":         "Perl",
+	".lua":        "Lua",
+	".r":          "R",
+	".jl":         "Julia",
+	".dart":       "Dart",
+	".ex":         "Elixir",
+	".exs":        "Elixir",
+	".erl":        "Erlang",
+	".hs":         "Haskell",
+	".ml":         "OCaml",
+	".clj":        "Clojure",
+	".elm":        "Elm",
+	".zig":        "Zig",
+	".nim":        "Nim",
+	".vue":        "Vue",
+	".svelte":     "Svelte",
+	".sh":         "Shell",
+	".bash":       "Shell",
+	".zsh":        "Shell",
+	".fish":       "fish",
+	".ps1":        "PowerShell",
+	".bat":        "Batchfile",
+	".cmd":        "Batchfile",
+	".sql":        "SQL",
+	".html":       "HTML",
+	".htm":        "HTML",
+	".css":        "CSS",
+	".scss":       "SCSS",
+	".sass":       "Sass",
+	".less":       "Less",
+	".md":         "Markdown",
+	".markdown":   "Markdown",
+	".mdx":        "MDX",
+	".rst":        "reStructuredText",
+	".txt":        "Text",
+	".json":       "JSON",
+	".jsonc":      "JSON with Comments",
+	".yaml":       "YAML",
+	".yml":        "YAML",
+	".toml":       "TOML",
+	".xml":        "XML",
+	".svg":        "SVG",
+	".ini":        "INI",
+	".cfg":        "INI",
+	".proto":      "Protocol Buffer",
+	".graphql":    "GraphQL",
+	".gql":        "GraphQL",
+	".tf":         "HCL",
+	".hcl":        "HCL",
+	".mk":         "Makefile",
+	".cmake":      "CMake",
+	".dockerfile": "Dockerfile",
+	".tex":        "TeX",
+	".csv":        "CSV",
+	".tsv":        "TSV",
+	".ipynb":      "Jupyter Notebook",
+}
+
+// interpreters map shebang interpreters, without version suffixes.
+var interpreters = map[string]string{
+	"python":  "Python",
+	"node":    "JavaScript",
+	"nodejs":  "JavaScript",
+	"deno":    "TypeScript",
+	"bun":     "TypeScript",
+	"ts-node": "TypeScript",
+	"sh":      "Shell",
+	"bash":    "Shell",
+	"zsh":     "Shell",
+	"dash":    "Shell",
+	"ksh":     "Shell",
+	"fish":    "fish",
+	"ruby":    "Ruby",
+	"perl":    "Perl",
+	"php":     "PHP",
+	"lua":     "Lua",
+	"rscript": "R",
+	"make":    "Makefile",
+	"pwsh":    "PowerShell",
+}
+
+// interpreterVersion strips "3.11" from "python3.11".
+var interpreterVersion = regexp.MustCompile(`[0-9.]+$`)
+
+// Detect returns the language of the file at relPath with content head
+// (the first line is enough). Whole file names win over extensions, and a
+// shebang classifies files with no known extension.
+func Detect(relPath string, head []byte) string {
+	name := path.Base(strings.ReplaceAll(relPath, "\\", "/"))
+	if lang, ok := exactFilenames[name]; ok {
+		return lang
+	}
+	base := strings.ToLower(name)
+	if lang, ok := filenames[base]; ok {
+		return lang
+	}
+	if strings.HasPrefix(base, "dockerfile.") || strings.HasPrefix(base, "makefile.") {
+		return filenames[base[:strings.IndexByte(base, '.')]]
+	}
+	if lang, ok := extensions[path.Ext(base)]; ok {
+		return lang
+	}
+	if lang := shebang(head); lang != "" {
+		return lang
+	}
+	return Other
+}
+
+// shebang returns the language named by a "#!" first line, or "".
+func shebang(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+	line := head[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// "#!/usr/bin/env -S deno run": skip env's own flags.
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+	interpreter = strings.ToLower(interpreterVersion.ReplaceAllString(interpreter, ""))
+	return interpreters[interpreter]
+}
@@ -0,0 +1,29 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path string
+		head string
+		want string
+	}{
+		{path: "cmd/main.go", want: "Go"},
+		{path: "web/App.TSX", want: "TSX"},
+		{path: "go.mod", want: "Go Module"},
+		{path: "Makefile", want: "Makefile"},
+		{path: "pkg/BUILD", want: "Starlark"},
+		{path: "docker/Dockerfile.dev", want: "Dockerfile"},
+		{path: "scripts/build", head: "#!/bin/bash\nset -e\n", want: "Shell"},
+		{path: "scripts/tool", head: "#!/usr/bin/env python3.11\n", want: "Python"},
+		{path: "scripts/run", head: "#!/usr/bin/env -S deno run --allow-read\n", want: "TypeScript"},
+		{path: "notes.py", head: "#!/bin/sh\n", want: "Python"},
+		{path: "data.bin2", head: "hello\n", want: Other},
+		{path: "scripts/unknown", head: "#!/opt/bin/frobnicate\n", want: Other},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.path, []byte(tt.head)); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,599 @@
+// Package lexical splits source files into code, comment, docstring and
+// blank lines. It scans comment and string syntax, not grammar, so it never
+// fails: files in languages it does not know are code and blank lines.
+package lexical
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind classifies a line or a byte of a file. It is a byte so a scan
+// marks a file with one byte per content byte.
+type Kind uint8
+
+const (
+	Code Kind = iota
+	Comment
+	Docstring
+	Blank
+	// literal marks string literal bytes during a scan; lines count them
+	// as code.
+	literal
+)
+
+// Split counts a file's lines by kind and keeps the text of its comments,
+// docstrings and blank lines, so each can be tokenized on its own.
+type Split struct {
+	CodeLines      int
+	CommentLines   int
+	DocstringLines int
+	BlankLines     int
+	// Comment and Docstring hold the commented text of each line that has
+	// any, one per line, including trailing comments on code lines.
+	Comment   string
+	Docstring string
+	// Blank holds the blank lines.
+	Blank string
+}
+
+// Classify splits content written in lang, a language.Detect name. A line
+// with any code is code, even with a trailing comment; a line with only
+// whitespace is blank, even inside a block comment.
+func Classify(lang string, content []byte) Split {
+	// Without comment syntax every byte is code; nil marks say so.
+	var marks []Kind
+	syn, ok := syntaxes[lang]
+	if ok {
+		marks = make([]Kind, len(content))
+		syn.scan(content, marks)
+	}
+	lines := splitLines(content, marks)
+	if syn.goDocComments {
+		markGoDocComments(lines)
+	}
+
+	var split Split
+	var comment, doc, blank strings.Builder
+	for _, l := range lines {
+		switch l.kind {
+		case Code:
+			split.CodeLines++
+		case Comment:
+			split.CommentLines++
+		case Docstring:
+			split.DocstringLines++
+		case Blank:
+			split.BlankLines++
+			blank.Write(l.text)
+			blank.WriteByte('\n')
+		}
+		if len(l.comment) > 0 {
+			comment.Write(l.comment)
+			comment.WriteByte('\n')
+		}
+		if len(l.doc) > 0 {
+			doc.Write(l.doc)
+			doc.WriteByte('\n')
+		}
+	}
+	split.Comment, split.Docstring, split.Blank = comment.String(), doc.String(), blank.String()
+	return split
+}
+
+// Mask returns a copy of content with comments, docstrings and string
+// literals blanked to spaces, keeping newlines and byte offsets, so a parser
+// can match brackets and keywords without tracking either.
+func Mask(lang string, content []byte) []byte {
+	masked := append([]byte(nil), content...)
+	syn, ok := syntaxes[lang]
+	if !ok {
+		return masked
+	}
+	marks := make([]Kind, len(content))
+	syn.scan(content, marks)
+	for i, kind := range marks {
+		if kind != Code && masked[i] != '\n' {
+			masked[i] = ' '
+		}
+	}
+	return masked
+}
+
+// Comments returns a copy of content with everything but its comments,
+// doc comments included, blanked to spaces, keeping newlines and byte
+// offsets. String literals and Python docstrings are blanked: they are
+// strings, not comment syntax.
+func Comments(lang string, content []byte) []byte {
+	comments := bytes.Repeat([]byte{' '}, len(content))
+	syn, ok := syntaxes[lang]
+	if ok {
+		marks := make([]Kind, len(content))
+		syn.scan(content, marks)
+		for i, kind := range marks {
+			if kind == Comment || kind == Docstring && !syn.pythonDocstrings {
+				comments[i] = content[i]
+			}
+		}
+	}
+	for i, c := range content {
+		if c == '\n' {
+			comments[i] = c
+		}
+	}
+	return comments
+}
+
+type line struct {
+	kind    Kind
+	text    []byte
+	comment []byte
+	doc     []byte
+}
+
+// splitLines classifies each line the way count.countLines counts them:
+// a trailing newline ends with an empty last line. nil marks are all code.
+func splitLines(content []byte, marks []Kind) []line {
+	if len(content) == 0 {
+		return nil
+	}
+	var lines []line
+	start := 0
+	for {
+		end := bytes.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += start
+		}
+		var lineMarks []Kind
+		if marks != nil {
+			lineMarks = marks[start:end]
+		}
+		lines = append(lines, classifyLine(content[start:end], lineMarks))
+		if end == len(content) {
+			return lines
+		}
+		start = end + 1
+	}
+}
+
+func classifyLine(text []byte, marks []Kind) line {
+	l := line{kind: Blank, text: text}
+	var hasCode, hasComment, hasDoc bool
+	for i, c := range text {
+		if isSpace(c) {
+			continue
+		}
+		if marks == nil {
+			hasCode = true
+			break
+		}
+		switch marks[i] {
+		case Code, literal:
+			hasCode = true
+		case Comment:
+			hasComment = true
+		case Docstring:
+			hasDoc = true
+		}
+	}
+	switch {
+	case hasCode:
+		l.kind = Code
+	case hasDoc:
+		l.kind = Docstring
+	case hasComment:
+		l.kind = Comment
+	}
+	if l.kind == Blank || marks == nil {
+		return l
+	}
+	l.comment = collect(text, marks, Comment)
+	l.doc = collect(text, marks, Docstring)
+	return l
+}
+
+// collect returns the bytes of text marked kind, trimmed.
+func collect(text []byte, marks []Kind, kind Kind) []byte {
+	var out []byte
+	for i, c := range text {
+		if marks[i] == kind {
+			out = append(out, c)
+		}
+	}
+	return bytes.TrimSpace(out)
+}
+
+// goDeclPrefixes start the top-level declarations a Go doc comment
+// documents.
+var goDeclPrefixes = []string{"package ", "func ", "func(", "type ", "var ", "const "}
+
+// markGoDocComments turns comment lines directly above a top-level
+// declaration into docstrings.
+func markGoDocComments(lines []line) {
+	run := -1
+	for i, l := range lines {
+		switch {
+		case l.kind == Comment:
+			if run < 0 {
+				run = i
+			}
+		case l.kind == Code && run >= 0 && hasAnyPrefix(l.text, goDeclPrefixes):
+			for j := run; j < i; j++ {
+				lines[j].kind = Docstring
+				lines[j].doc, lines[j].comment = lines[j].comment, nil
+			}
+			run = -1
+		default:
+			run = -1
+		}
+	}
+}
+
+func hasAnyPrefix(text []byte, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(text, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == '\v'
+}
+
+// delimiters open and close a block comment.
+type delimiters struct {
+	open, close string
+}
+
+// quote is a string literal syntax.
+type quote struct {
+	open, close string
+	// multiline strings may span lines; others end at a newline even when
+	// unterminated, so a stray quote cannot swallow the rest of the file.
+	multiline bool
+	escapes   bool
+}
+
+// syntax is the comment and string syntax of a language family.
+type syntax struct {
+	docLines     []string
+	lineComments []string
+	// wordStartComments only start a line comment after whitespace or at
+	// the start of a line, as in shell ("$#" and "${#x}" are code).
+	wordStartComments bool
+	docBlocks         []delimiters
+	blockComments     []delimiters
+	// quotes are tried in order, so triple quotes come before single ones.
+	quotes []quote
+	// pythonDocstrings marks a triple-quoted string that starts a line
+	// after a ":" (or at the top of the file) as a docstring.
+	pythonDocstrings bool
+	// goDocComments marks comments above top-level declarations as
+	// docstrings.
+	goDocComments bool
+	// rust enables raw strings and tells char literals from lifetimes.
+	rust bool
+}
+
+var (
+	doubleQuote   = quote{open: `"`, close: `"`, escapes: true}
+	singleQuote   = quote{open: `'`, close: `'`, escapes: true}
+	rawSingle     = quote{open: `'`, close: `'`}
+	backtick      = quote{open: "`", close: "`", multiline: true, escapes: true}
+	tripleDouble  = quote{open: `"""`, close: `"""`, multiline: true, escapes: true}
+	tripleSingle  = quote{open: `'''`, close: `'''`, multiline: true, escapes: true}
+	cBlock        = delimiters{open: "/*", close: "*/"}
+	javadocBlock  = delimiters{open: "/**", close: "*/"}
+	innerDocBlock = delimiters{open: "/*!", close: "*/"}
+	htmlBlock     = delimiters{open: "<!--", close: "-->"}
+)
+
+var (
+	cSyntax = syntax{
+		docLines:      []string{"///", "//!"},
+		lineComments:  []string{"//"},
+		docBlocks:     []delimiters{javadocBlock, innerDocBlock},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{doubleQuote, singleQuote},
+	}
+	javaSyntax = syntax{
+		lineComments:  []string{"//"},
+		docBlocks:     []delimiters{javadocBlock},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{tripleDouble, doubleQuote, singleQuote},
+	}
+	jsSyntax = syntax{
+		lineComments:  []string{"//"},
+		docBlocks:     []delimiters{javadocBlock},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{doubleQuote, singleQuote, backtick},
+	}
+	swiftSyntax = syntax{
+		docLines:      []string{"///"},
+		lineComments:  []string{"//"},
+		docBlocks:     []delimiters{javadocBlock},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{tripleDouble, doubleQuote},
+	}
+	goSyntax = syntax{
+		lineComments:  []string{"//"},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{doubleQuote, singleQuote, {open: "`", close: "`", multiline: true}},
+		goDocComments: true,
+	}
+	rustSyntax = syntax{
+		docLines:      []string{"///", "//!"},
+		lineComments:  []string{"//"},
+		docBlocks:     []delimiters{javadocBlock, innerDocBlock},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{{open: `"`, close: `"`, multiline: true, escapes: true}},
+		rust:          true,
+	}
+	pythonSyntax = syntax{
+		lineComments:     []string{"#"},
+		quotes:           []quote{tripleDouble, tripleSingle, doubleQuote, singleQuote},
+		pythonDocstrings: true,
+	}
+	hashSyntax = syntax{
+		lineComments:      []string{"#"},
+		wordStartComments: true,
+		quotes:            []quote{doubleQuote, rawSingle},
+	}
+	cssSyntax = syntax{
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{doubleQuote, singleQuote},
+	}
+	scssSyntax = syntax{
+		lineComments:  []string{"//"},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{doubleQuote, singleQuote},
+	}
+	sqlSyntax = syntax{
+		lineComments:  []string{"--"},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{rawSingle, {open: `"`, close: `"`}},
+	}
+	luaSyntax = syntax{
+		docLines:      []string{"---"},
+		lineComments:  []string{"--"},
+		blockComments: []delimiters{{open: "--[[", close: "]]"}},
+		quotes:        []quote{doubleQuote, singleQuote},
+	}
+	markupSyntax = syntax{
+		blockComments: []delimiters{htmlBlock},
+	}
+)
+
+// syntaxes maps language.Detect names to their syntax.
+var syntaxes = map[string]syntax{
+	"Go":            goSyntax,
+	"C":             cSyntax,
+	"C++":           cSyntax,
+	"Objective-C":   cSyntax,
+	"Objective-C++": cSyntax,
+	"C#":            cSyntax,
+	"Dart":          cSyntax,
+	"Protocol Buffer": {
+		lineComments:  []string{"//"},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{doubleQuote, singleQuote},
+	},
+	"Java":       javaSyntax,
+	"Kotlin":     javaSyntax,
+	"Scala":      javaSyntax,
+	"Groovy":     javaSyntax,
+	"Gradle":     javaSyntax,
+	"PHP":        jsSyntax,
+	"JavaScript": jsSyntax,
+	"TypeScript": jsSyntax,
+	"TSX":        jsSyntax,
+	"Swift":      swiftSyntax,
+	"Rust":       rustSyntax,
+	"Python":     pythonSyntax,
+	"Shell":      hashSyntax,
+	"fish":       hashSyntax,
+	"PowerShell": hashSyntax,
+	"YAML":       hashSyntax,
+	"TOML":       hashSyntax,
+	"Ruby":       hashSyntax,
+	"Perl":       hashSyntax,
+	"R":          hashSyntax,
+	"Elixir":     hashSyntax,
+	"Makefile":   hashSyntax,
+	"Dockerfile": hashSyntax,
+	"CMake":      hashSyntax,
+	"Starlark":   pythonSyntax,
+	"HCL": {
+		lineComments:  []string{"#", "//"},
+		blockComments: []delimiters{cBlock},
+		quotes:        []quote{doubleQuote},
+	},
+	"CSS":      cssSyntax,
+	"SCSS":     scssSyntax,
+	"Less":     scssSyntax,
+	"SQL":      sqlSyntax,
+	"Lua":      luaSyntax,
+	"Haskell":  {lineComments: []string{"--"}, blockComments: []delimiters{{open: "{-", close: "-}"}}, quotes: []quote{doubleQuote}},
+	"HTML":     markupSyntax,
+	"XML":      markupSyntax,
+	"SVG":      markupSyntax,
+	"Vue":      markupSyntax,
+	"Svelte":   markupSyntax,
+	"Markdown": markupSyntax,
+}
+
+// scan marks every comment and docstring byte of src; the rest stay Code.
+func (s syntax) scan(src []byte, marks []Kind) {
+	var lastCode byte
+	for i := 0; i < len(src); {
+		c := src[i]
+		if isSpace(c) {
+			i++
+			continue
+		}
+		if end, kind, ok := s.comment(src, i); ok {
+			mark(marks, i, end, kind)
+			i = end
+			continue
+		}
+		if end, ok := s.rawString(src, i); ok {
+			mark(marks, i, end, literal)
+			lastCode = '"'
+			i = end
+			continue
+		}
+		if end, q, ok := s.str(src, i); ok {
+			if s.pythonDocstrings && q.multiline && (lastCode == 0 || lastCode == ':') && startsLine(src, i) {
+				mark(marks, i, end, Docstring)
+			} else {
+				mark(marks, i, end, literal)
+				lastCode = q.close[len(q.close)-1]
+			}
+			i = end
+			continue
+		}
+		lastCode = c
+		i++
+	}
+}
+
+func (s syntax) comment(src []byte, i int) (int, Kind, bool) {
+	rest := src[i:]
+	for _, block := range s.docBlocks {
+		// "/**/" is an empty block comment, not a doc comment.
+		if bytes.HasPrefix(rest, []byte(block.open)) && !bytes.HasPrefix(rest, []byte("/**/")) {
+			return blockEnd(src, i+len(block.open), block.close), Docstring, true
+		}
+	}
+	// Blocks come before line comments so Lua's "--[[" is not read as "--".
+	for _, block := range s.blockComments {
+		if bytes.HasPrefix(rest, []byte(block.open)) {
+			return blockEnd(src, i+len(block.open), block.close), Comment, true
+		}
+	}
+	for _, open := range s.docLines {
+		if bytes.HasPrefix(rest, []byte(open)) {
+			return lineEnd(src, i), Docstring, true
+		}
+	}
+	if !s.wordStartComments || i == 0 || isSpace(src[i-1]) {
+		for _, open := range s.lineComments {
+			if bytes.HasPrefix(rest, []byte(open)) {
+				return lineEnd(src, i), Comment, true
+			}
+		}
+	}
+	return 0, Code, false
+}
+
+func (s syntax) str(src []byte, i int) (int, quote, bool) {
+	rest := src[i:]
+	for _, q := range s.quotes {
+		if !bytes.HasPrefix(rest, []byte(q.open)) {
+			continue
+		}
+		return stringEnd(src, i+len(q.open), q), q, true
+	}
+	if s.rust && src[i] == '\'' {
+		if end, ok := rustChar(src, i); ok {
+			return end, singleQuote, true
+		}
+	}
+	return 0, quote{}, false
+}
+
+// rawString matches a Rust raw string, r"..." or r#"..."#, which has no
+// escapes and may contain quotes.
+func (s syntax) rawString(src []byte, i int) (int, bool) {
+	if !s.rust || src[i] != 'r' || (i > 0 && isIdent(src[i-1]) && src[i-1] != 'b') {
+		return 0, false
+	}
+	j := i + 1
+	for j < len(src) && src[j] == '#' {
+		j++
+	}
+	if j >= len(src) || src[j] != '"' {
+		return 0, false
+	}
+	closing := `"` + strings.Repeat("#", j-i-1)
+	if end := bytes.Index(src[j+1:], []byte(closing)); end >= 0 {
+		return j + 1 + end + len(closing), true
+	}
+	return len(src), true
+}
+
+// rustChar matches a char literal ('a', '\n', '\u{1F600}') but not a
+// lifetime ('a).
+func rustChar(src []byte, i int) (int, bool) {
+	if i+1 >= len(src) {
+		return 0, false
+	}
+	if src[i+1] == '\\' {
+		for j := i + 2; j < len(src) && j < i+12; j++ {
+			if src[j] == '\'' {
+				return j + 1, true
+			}
+		}
+		return 0, false
+	}
+	_, size := utf8.DecodeRune(src[i+1:])
+	if end := i + 1 + size; end < len(src) && src[end] == '\'' {
+		return end + 1, true
+	}
+	return 0, false
+}
+
+func stringEnd(src []byte, j int, q quote) int {
+	for j < len(src) {
+		switch {
+		case q.escapes && src[j] == '\\':
+			j += 2
+			continue
+		case !q.multiline && src[j] == '\n':
+			return j
+		case bytes.HasPrefix(src[j:], []byte(q.close)):
+			return j + len(q.close)
+		}
+		j++
+	}
+	return len(src)
+}
+
+func lineEnd(src []byte, i int) int {
+	if end := bytes.IndexByte(src[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(src)
+}
+
+func blockEnd(src []byte, j int, close string) int {
+	if end := bytes.Index(src[j:], []byte(close)); end >= 0 {
+		return j + end + len(close)
+	}
+	return len(src)
+}
+
+// startsLine reports whether only whitespace precedes src[i] on its line.
+func startsLine(src []byte, i int) bool {
+	for j := i - 1; j >= 0 && src[j] != '\n'; j-- {
+		if !isSpace(src[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func mark(marks []Kind, start int, end int, kind Kind) {
+	for i := start; i < end && i < len(marks); i++ {
+		marks[i] = kind
+	}
+}
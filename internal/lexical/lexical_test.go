@@ -0,0 +1,104 @@
+package lexical
+
+import "testing"
+
+func TestClassify_Lines(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		src     string
+		code    int
+		comment int
+		doc     int
+		blank   int
+	}{
+		{
+			name: "go doc comments precede declarations",
+			lang: "Go",
+			src: "// Package p does things.\npackage p\n\n" +
+				"// Run runs.\nfunc Run() {\n\t// step one\n\tx := \"// not a comment\" // trailing\n\t_ = x\n}\n",
+			code: 5, comment: 1, doc: 2, blank: 2,
+		},
+		{
+			name: "go raw strings and block comments",
+			lang: "Go",
+			src:  "var s = `\n/* inside */\n`\n/*\nblock\n\n*/\n",
+			code: 3, comment: 3, blank: 2,
+		},
+		{
+			name: "python docstrings and hash comments",
+			lang: "Python",
+			src: "#!/usr/bin/env python3\n\"\"\"Module doc.\"\"\"\n\ndef f(x):\n" +
+				"    \"\"\"Doc line one.\n\n    Doc line three.\n    \"\"\"\n    s = \"\"\"# not a comment\"\"\"\n    return x  # trailing\n",
+			code: 3, comment: 1, doc: 4, blank: 3,
+		},
+		{
+			name: "javascript jsdoc and templates",
+			lang: "TypeScript",
+			src:  "/**\n * Adds.\n */\nexport const add = (a, b) => `${a}//${b}`;\n/**/\n// done\n",
+			code: 1, comment: 2, doc: 3, blank: 1,
+		},
+		{
+			name: "rust doc lines, lifetimes and raw strings",
+			lang: "Rust",
+			src:  "//! Crate doc.\n/// Item doc.\nfn f<'a>(s: &'a str) -> char {\n    let _ = r#\"// \"quoted\" \"#;\n    '\"' // quote char\n}\n",
+			code: 4, doc: 2, blank: 1,
+		},
+		{
+			name: "shell comments start words",
+			lang: "Shell",
+			src:  "#!/bin/sh\n# usage\necho \"$#\" ${#x} 'a # b' # trailing\n",
+			code: 1, comment: 2, blank: 1,
+		},
+		{
+			name: "yaml",
+			lang: "YAML",
+			src:  "# config\nkey: \"a#b\" # note\nurl: http://x/#frag\n",
+			code: 2, comment: 1, blank: 1,
+		},
+		{
+			name: "unknown languages are code and blank",
+			lang: "Text",
+			src:  "// hello\n\n# world",
+			code: 2, blank: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.lang, []byte(tt.src))
+			if got.CodeLines != tt.code || got.CommentLines != tt.comment || got.DocstringLines != tt.doc || got.BlankLines != tt.blank {
+				t.Fatalf("expected code/comment/doc/blank %d/%d/%d/%d, got %d/%d/%d/%d",
+					tt.code, tt.comment, tt.doc, tt.blank,
+					got.CodeLines, got.CommentLines, got.DocstringLines, got.BlankLines)
+			}
+		})
+	}
+}
+
+func TestClassify_Text(t *testing.T) {
+	got := Classify("Go", []byte("// Doc.\nfunc f() {\n\treturn // why\n}\n"))
+	if got.Docstring != "// Doc.\n" {
+		t.Fatalf("expected the doc comment text, got %q", got.Docstring)
+	}
+	if got.Comment != "// why\n" {
+		t.Fatalf("expected the trailing comment text, got %q", got.Comment)
+	}
+	if got.Blank != "\n" {
+		t.Fatalf("expected the final empty line, got %q", got.Blank)
+	}
+}
+
+func TestComments_KeepsOnlyCommentSyntax(t *testing.T) {
+	tests := []struct {
+		lang, src, want string
+	}{
+		{"Python", "# c\ndef f():\n    \"\"\"doc\"\"\"\n    s = '#x'\n", "# c\n        \n             \n            \n"},
+		{"Rust", "/// doc\nfn f() { \"//\" } // t\n", "/// doc\n                // t\n"},
+		{"Unknown", "# not a comment\n", "               \n"},
+	}
+	for _, tt := range tests {
+		if got := string(Comments(tt.lang, []byte(tt.src))); got != tt.want {
+			t.Errorf("%s: Comments(%q) = %q, want %q", tt.lang, tt.src, got, tt.want)
+		}
+	}
+}
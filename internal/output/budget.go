@@ -0,0 +1,26 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/budget"
+)
+
+// RenderBudgetViolations returns one line per exceeded budget.
+func RenderBudgetViolations(violations []budget.Violation) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Budget check failed (%d violation(s)):\n", len(violations)))
+	for _, v := range violations {
+		over := v.Actual - v.Limit
+		switch v.Rule {
+		case budget.RuleTotal:
+			b.WriteString(fmt.Sprintf("  total: %s tokens > %s limit (+%s)\n", formatInt(v.Actual), formatInt(v.Limit), formatInt(over)))
+		case budget.RuleUnknownDirectory:
+			b.WriteString(fmt.Sprintf("  directory %s: no counted files under this path; check the rule for a typo or a renamed directory\n", v.Path))
+		default:
+			b.WriteString(fmt.Sprintf("  %s %s: %s tokens > %s limit (+%s)\n", v.Rule, v.Path, formatInt(v.Actual), formatInt(v.Limit), formatInt(over)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/calibrate"
+)
+
+// RenderCalibration returns the human-readable calibration report.
+// written is the path the ratio file was saved to, if any.
+func RenderCalibration(report calibrate.Report, written string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Reference tokenizer: %s\n", report.Reference))
+	b.WriteString(fmt.Sprintf("Sampled files: %s of %s\n", formatInt(report.SampledFiles), formatInt(report.CandidateFiles)))
+	b.WriteString("\n")
+
+	b.WriteString("Chars/token by extension:\n")
+	if len(report.Extensions) == 0 {
+		b.WriteString("  (no files with an extension)\n")
+	} else {
+		b.WriteString(fmt.Sprintf("  %-12s %6s %12s %8s  %-13s %s\n", "extension", "files", "tokens", "ratio", "current err", "fitted err"))
+		for _, fit := range report.Extensions {
+			ratio := fmt.Sprintf("%.2f", fit.CharsPerToken)
+			if !fit.Written {
+				ratio += "*"
+			}
+			b.WriteString(fmt.Sprintf("  %-12s %6s %12s %8s  %-13s %s\n",
+				fit.Extension, formatInt(fit.Files), formatInt(fit.Tokens), ratio,
+				formatErrorFit(fit.Current), formatErrorFit(fit.Calibrated)))
+		}
+	}
+	b.WriteString(fmt.Sprintf("  %-12s %6s %12s %8.2f\n", "(fallback)", formatInt(report.SampledFiles), formatInt(report.ActualTokens), report.CharsPerToken))
+	b.WriteString("\n")
+
+	b.WriteString("Error bounds (per file, |estimate/actual - 1|, mean / 90th percentile):\n")
+	b.WriteString(fmt.Sprintf("  Current estimate:    %s\n", formatErrorFit(report.Current)))
+	b.WriteString(fmt.Sprintf("  Calibrated estimate: %s (leave-one-out)\n", formatErrorFit(report.Calibrated)))
+	if report.ActualTokens > 0 {
+		drift := float64(report.CurrentTokens)/float64(report.ActualTokens)*100 - 100
+		b.WriteString(fmt.Sprintf("  Current estimate of the sampled total: %s vs %s actual (%+.1f%%)\n",
+			formatInt(report.CurrentTokens), formatInt(report.ActualTokens), drift))
+	}
+	b.WriteString("  * too few sampled files; the fallback ratio applies\n")
+
+	if written != "" {
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Wrote %s. Load it with `estimate: {ratios_file: ...}` in .tokcount.yaml or `--tokenizer estimate --ratios-file %s`.\n", written, written))
+	}
+	return b.String()
+}
+
+func formatErrorFit(fit calibrate.ErrorFit) string {
+	if fit.Files == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%% / %.1f%%", fit.Mean*100, fit.P90*100)
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+// renderComparisonTotals writes each tokenizer's total and its difference
+// from the primary tokenizer.
+func renderComparisonTotals(b *strings.Builder, result *count.Result) {
+	width := comparisonNameWidth(result)
+	b.WriteString("Totals by tokenizer:\n")
+	for i, total := range result.Comparison {
+		delta := ""
+		if i > 0 && result.TotalTokens > 0 {
+			delta = fmt.Sprintf("%+.1f%%", (float64(total.TotalTokens)/float64(result.TotalTokens)-1)*100)
+		}
+		b.WriteString(fmt.Sprintf("  %-*s %12s tokens %8s  %s\n", width, total.Name, formatInt(total.TotalTokens), delta, total.Description))
+	}
+}
+
+// renderComparisonRows writes one column of tokens per tokenizer for each
+// path, in the order of the rows.
+func renderComparisonRows(b *strings.Builder, result *count.Result, paths []string, counts []map[string]int) {
+	pathWidth := 22
+	for _, path := range paths {
+		if len(path) > pathWidth {
+			pathWidth = len(path)
+		}
+	}
+	width := comparisonNameWidth(result)
+	if width < 12 {
+		width = 12
+	}
+
+	b.WriteString(fmt.Sprintf("  %-*s", pathWidth, ""))
+	for _, total := range result.Comparison {
+		b.WriteString(fmt.Sprintf(" %*s", width, total.Name))
+	}
+	b.WriteString("\n")
+	for i, path := range paths {
+		b.WriteString(fmt.Sprintf("  %-*s", pathWidth, path))
+		for _, total := range result.Comparison {
+			b.WriteString(fmt.Sprintf(" %*s", width, formatInt(counts[i][total.Name])))
+		}
+		b.WriteString("\n")
+	}
+}
+
+func comparisonNameWidth(result *count.Result) int {
+	width := 0
+	for _, total := range result.Comparison {
+		if len(total.Name) > width {
+			width = len(total.Name)
+		}
+	}
+	return width
+}
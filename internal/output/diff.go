@@ -0,0 +1,235 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+// File change statuses reported by ComputeDiff.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// Diff is the token delta between two count results.
+type Diff struct {
+	Base        string           `json:"base"`
+	Head        string           `json:"head"`
+	Tokenizer   string           `json:"tokenizer"`
+	BaseTokens  int              `json:"base_tokens"`
+	HeadTokens  int              `json:"head_tokens"`
+	DeltaTokens int              `json:"delta_tokens"`
+	BaseFiles   int              `json:"base_files"`
+	HeadFiles   int              `json:"head_files"`
+	Files       []FileDelta      `json:"files"`
+	Directories []DirectoryDelta `json:"directories"`
+}
+
+// FileDelta is a per-file token change.
+type FileDelta struct {
+	Path       string `json:"path"`
+	Status     string `json:"status"`
+	BaseTokens int    `json:"base_tokens"`
+	HeadTokens int    `json:"head_tokens"`
+	Delta      int    `json:"delta"`
+}
+
+// DirectoryDelta is a per-directory token change.
+type DirectoryDelta struct {
+	Path       string `json:"path"`
+	BaseTokens int    `json:"base_tokens"`
+	HeadTokens int    `json:"head_tokens"`
+	Delta      int    `json:"delta"`
+}
+
+// ComputeDiff compares two results file by file and directory by directory.
+// Files whose token count did not change are omitted.
+func ComputeDiff(baseLabel string, base *count.Result, headLabel string, head *count.Result) Diff {
+	diff := Diff{
+		Base:        baseLabel,
+		Head:        headLabel,
+		Tokenizer:   head.Tokenizer,
+		BaseTokens:  base.TotalTokens,
+		HeadTokens:  head.TotalTokens,
+		DeltaTokens: head.TotalTokens - base.TotalTokens,
+		BaseFiles:   base.TotalFiles,
+		HeadFiles:   head.TotalFiles,
+	}
+
+	baseFiles := make(map[string]int, len(base.Files))
+	for _, file := range base.Files {
+		baseFiles[file.Path] = file.Tokens
+	}
+	headFiles := make(map[string]int, len(head.Files))
+	for _, file := range head.Files {
+		headFiles[file.Path] = file.Tokens
+	}
+
+	for path, headTokens := range headFiles {
+		baseTokens, ok := baseFiles[path]
+		switch {
+		case !ok:
+			diff.Files = append(diff.Files, FileDelta{Path: path, Status: DiffAdded, HeadTokens: headTokens, Delta: headTokens})
+		case baseTokens != headTokens:
+			diff.Files = append(diff.Files, FileDelta{Path: path, Status: DiffChanged, BaseTokens: baseTokens, HeadTokens: headTokens, Delta: headTokens - baseTokens})
+		}
+	}
+	for path, baseTokens := range baseFiles {
+		if _, ok := headFiles[path]; !ok {
+			diff.Files = append(diff.Files, FileDelta{Path: path, Status: DiffRemoved, BaseTokens: baseTokens, Delta: -baseTokens})
+		}
+	}
+	sort.Slice(diff.Files, func(i, j int) bool {
+		return lessByDelta(diff.Files[i].Delta, diff.Files[i].Path, diff.Files[j].Delta, diff.Files[j].Path)
+	})
+
+	dirs := make(map[string]*DirectoryDelta)
+	for _, stat := range AllDirectoryStats(base) {
+		dirs[stat.Path] = &DirectoryDelta{Path: stat.Path, BaseTokens: stat.Tokens}
+	}
+	for _, stat := range AllDirectoryStats(head) {
+		row := dirs[stat.Path]
+		if row == nil {
+			row = &DirectoryDelta{Path: stat.Path}
+			dirs[stat.Path] = row
+		}
+		row.HeadTokens = stat.Tokens
+	}
+	for _, row := range dirs {
+		row.Delta = row.HeadTokens - row.BaseTokens
+		if row.Delta != 0 {
+			diff.Directories = append(diff.Directories, *row)
+		}
+	}
+	sort.Slice(diff.Directories, func(i, j int) bool {
+		return lessByDelta(diff.Directories[i].Delta, diff.Directories[i].Path, diff.Directories[j].Delta, diff.Directories[j].Path)
+	})
+
+	return diff
+}
+
+// RenderDiffSummary returns human-readable diff output.
+func RenderDiffSummary(diff Diff) string {
+	var b strings.Builder
+	dirs, moreDirs := topRows(len(diff.Directories), defaultTopLimit)
+	files, moreFiles := topRows(len(diff.Files), defaultTopLimit)
+
+	b.WriteString(fmt.Sprintf("Base: %s\n", diff.Base))
+	b.WriteString(fmt.Sprintf("Head: %s\n", diff.Head))
+	b.WriteString(fmt.Sprintf("Tokenizer: %s\n", diff.Tokenizer))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("Total: %s -> %s tokens (%s)\n", formatInt(diff.BaseTokens), formatInt(diff.HeadTokens), formatDelta(diff.DeltaTokens)))
+	b.WriteString(fmt.Sprintf("Files: %s -> %s\n", formatInt(diff.BaseFiles), formatInt(diff.HeadFiles)))
+	b.WriteString("\n")
+
+	b.WriteString("Directory changes:\n")
+	if dirs == 0 {
+		b.WriteString("  (no directory changes)\n")
+	} else {
+		for _, row := range diff.Directories[:dirs] {
+			b.WriteString(fmt.Sprintf("  %-22s %12s tokens\n", row.Path, formatDelta(row.Delta)))
+		}
+		if moreDirs > 0 {
+			b.WriteString(fmt.Sprintf("  ... %d more directories\n", moreDirs))
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString("File changes:\n")
+	if files == 0 {
+		b.WriteString("  (no file changes)\n")
+	} else {
+		for _, row := range diff.Files[:files] {
+			b.WriteString(fmt.Sprintf("  %-8s %-22s %12s tokens\n", row.Status, row.Path, formatDelta(row.Delta)))
+		}
+		if moreFiles > 0 {
+			b.WriteString(fmt.Sprintf("  ... %d more files\n", moreFiles))
+		}
+	}
+
+	return b.String()
+}
+
+// RenderDiffJSON marshals machine-readable diff output.
+func RenderDiffJSON(diff Diff) ([]byte, error) {
+	if diff.Files == nil {
+		diff.Files = []FileDelta{}
+	}
+	if diff.Directories == nil {
+		diff.Directories = []DirectoryDelta{}
+	}
+	return json.MarshalIndent(diff, "", "  ")
+}
+
+// RenderDiffMarkdown returns a pull-request friendly diff report.
+func RenderDiffMarkdown(diff Diff) string {
+	var b strings.Builder
+	dirs, moreDirs := topRows(len(diff.Directories), defaultTopLimit)
+	files, moreFiles := topRows(len(diff.Files), defaultTopLimit)
+
+	b.WriteString(fmt.Sprintf("### Token diff: `%s` → `%s`\n\n", diff.Base, diff.Head))
+	b.WriteString(fmt.Sprintf("**Total:** %s → %s tokens (**%s**) · tokenizer `%s`\n\n",
+		formatInt(diff.BaseTokens), formatInt(diff.HeadTokens), formatDelta(diff.DeltaTokens), diff.Tokenizer))
+
+	if dirs > 0 {
+		b.WriteString("| Directory | Base | Head | Δ |\n")
+		b.WriteString("|---|---:|---:|---:|\n")
+		for _, row := range diff.Directories[:dirs] {
+			b.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", row.Path, formatInt(row.BaseTokens), formatInt(row.HeadTokens), formatDelta(row.Delta)))
+		}
+		if moreDirs > 0 {
+			b.WriteString(fmt.Sprintf("\n_… %d more directories_\n", moreDirs))
+		}
+		b.WriteString("\n")
+	}
+
+	if files > 0 {
+		b.WriteString("| File | Status | Base | Head | Δ |\n")
+		b.WriteString("|---|---|---:|---:|---:|\n")
+		for _, row := range diff.Files[:files] {
+			b.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n", row.Path, row.Status, formatInt(row.BaseTokens), formatInt(row.HeadTokens), formatDelta(row.Delta)))
+		}
+		if moreFiles > 0 {
+			b.WriteString(fmt.Sprintf("\n_… %d more files_\n", moreFiles))
+		}
+	}
+
+	if dirs == 0 && files == 0 {
+		b.WriteString("No token changes.\n")
+	}
+	return b.String()
+}
+
+func topRows(total int, limit int) (int, int) {
+	if limit <= 0 || limit >= total {
+		return total, 0
+	}
+	return limit, total - limit
+}
+
+// lessByDelta orders rows by absolute delta desc, then path.
+func lessByDelta(deltaA int, pathA string, deltaB int, pathB string) bool {
+	absA, absB := deltaA, deltaB
+	if absA < 0 {
+		absA = -absA
+	}
+	if absB < 0 {
+		absB = -absB
+	}
+	if absA == absB {
+		return pathA < pathB
+	}
+	return absA > absB
+}
+
+func formatDelta(v int) string {
+	if v > 0 {
+		return "+" + formatInt(v)
+	}
+	return formatInt(v)
+}
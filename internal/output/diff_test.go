@@ -0,0 +1,60 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+func TestComputeDiff_FileAndDirectoryDeltas(t *testing.T) {
+	base := &count.Result{
+		TotalTokens:     300,
+		TotalFiles:      3,
+		DirectoryTokens: map[string]int{".": 300, "src": 200, "docs": 100},
+		Files: []count.FileStat{
+			{Path: "src/a.go", Tokens: 120},
+			{Path: "src/b.go", Tokens: 80},
+			{Path: "docs/old.md", Tokens: 100},
+		},
+	}
+	head := &count.Result{
+		TotalTokens:     350,
+		TotalFiles:      3,
+		DirectoryTokens: map[string]int{".": 350, "src": 350},
+		Files: []count.FileStat{
+			{Path: "src/a.go", Tokens: 150},
+			{Path: "src/b.go", Tokens: 80},
+			{Path: "src/c.go", Tokens: 120},
+		},
+	}
+
+	diff := ComputeDiff("base", base, "head", head)
+
+	if diff.DeltaTokens != 50 {
+		t.Fatalf("expected total delta 50, got %d", diff.DeltaTokens)
+	}
+
+	want := []FileDelta{
+		{Path: "src/c.go", Status: DiffAdded, HeadTokens: 120, Delta: 120},
+		{Path: "docs/old.md", Status: DiffRemoved, BaseTokens: 100, Delta: -100},
+		{Path: "src/a.go", Status: DiffChanged, BaseTokens: 120, HeadTokens: 150, Delta: 30},
+	}
+	if len(diff.Files) != len(want) {
+		t.Fatalf("expected %d file deltas, got %+v", len(want), diff.Files)
+	}
+	for i := range want {
+		if diff.Files[i] != want[i] {
+			t.Fatalf("file delta %d: expected %+v, got %+v", i, want[i], diff.Files[i])
+		}
+	}
+
+	if len(diff.Directories) != 2 {
+		t.Fatalf("expected 2 directory deltas, got %+v", diff.Directories)
+	}
+	if diff.Directories[0].Path != "src/" || diff.Directories[0].Delta != 150 {
+		t.Fatalf("expected src/ +150 first, got %+v", diff.Directories[0])
+	}
+	if diff.Directories[1].Path != "docs/" || diff.Directories[1].Delta != -100 {
+		t.Fatalf("expected docs/ -100 second, got %+v", diff.Directories[1])
+	}
+}
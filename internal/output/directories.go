@@ -12,6 +12,10 @@ type DirectoryStat struct {
 	Path       string  `json:"path"`
 	Tokens     int     `json:"tokens"`
 	Percentage float64 `json:"percentage"`
+	// Counts maps tokenizer label to tokens in a comparison scan.
+	Counts map[string]int `json:"counts,omitempty"`
+	// Fit maps context window name to fit status in JSON output.
+	Fit map[string]string `json:"fit,omitempty"`
 }
 
 // AllDirectoryStats returns all non-root directories sorted by tokens desc.
@@ -29,6 +33,7 @@ func AllDirectoryStats(result *count.Result) []DirectoryStat {
 			Path:       normalizeDirectoryPath(path),
 			Tokens:     tokens,
 			Percentage: pct,
+			Counts:     directoryCounts(result, path),
 		})
 	}
 	sort.Slice(stats, func(i, j int) bool {
@@ -49,6 +54,19 @@ func TopDirectoryStats(result *count.Result, limit int) ([]DirectoryStat, int) {
 	return all[:limit], len(all) - limit
 }
 
+// directoryCounts returns the per-tokenizer tokens of a directory, or nil
+// outside a comparison scan.
+func directoryCounts(result *count.Result, path string) map[string]int {
+	if len(result.Comparison) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(result.Comparison))
+	for _, total := range result.Comparison {
+		counts[total.Name] = total.DirectoryTokens[path]
+	}
+	return counts
+}
+
 func normalizeDirectoryPath(path string) string {
 	path = filepath.ToSlash(path)
 	if path == "." || path == "" {
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/ignore"
+)
+
+// RenderExplanations returns one line per path describing its deciding rule.
+func RenderExplanations(explanations []count.Explanation, root string) string {
+	var b strings.Builder
+	for _, exp := range explanations {
+		b.WriteString(exp.Path)
+		b.WriteString(": ")
+		switch exp.Reason {
+		case count.ReasonPattern:
+			b.WriteString(fmt.Sprintf("ignored by %s", formatPattern(exp.Match, root)))
+			if exp.Directory != "" {
+				b.WriteString(fmt.Sprintf(" (parent directory %s)", exp.Directory))
+			}
+		case count.ReasonNotIncluded:
+			b.WriteString("ignored; matched no include pattern")
+		case count.ReasonOversized:
+			b.WriteString(fmt.Sprintf("skipped; %s bytes exceeds the %s-byte limit", formatInt(int(exp.Bytes)), formatInt(int(exp.Limit))))
+		case count.ReasonBinary:
+			b.WriteString("skipped; content looks binary")
+		case count.ReasonUnreadable:
+			b.WriteString("skipped; file is unreadable")
+		default:
+			b.WriteString(fmt.Sprintf("counted (%s bytes)", formatInt(int(exp.Bytes))))
+			if exp.Match != nil && exp.Match.Negated {
+				b.WriteString(fmt.Sprintf("; re-included by %s", formatPattern(exp.Match, root)))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatPattern renders source:line:pattern like git check-ignore -v.
+func formatPattern(match *ignore.Match, root string) string {
+	if match == nil {
+		return "(unknown rule)"
+	}
+	source := match.Pattern.Source
+	if filepath.IsAbs(source) {
+		if rel, err := filepath.Rel(root, source); err == nil && !strings.HasPrefix(rel, "..") {
+			source = filepath.ToSlash(rel)
+		}
+	}
+	return fmt.Sprintf("%s:%d:%s", source, match.Pattern.Line, match.Pattern.Text)
+}
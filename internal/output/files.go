@@ -0,0 +1,72 @@
+package output
+
+import (
+	"sort"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/symbols"
+)
+
+// FileStat is a normalized file-level token row.
+type FileStat struct {
+	Path       string      `json:"path"`
+	Tokens     int         `json:"tokens"`
+	Bytes      int64       `json:"bytes"`
+	Lines      int         `json:"lines"`
+	Extension  string      `json:"extension"`
+	Language   string      `json:"language"`
+	Percentage float64     `json:"percentage"`
+	TokenSplit count.Split `json:"token_split"`
+	LineSplit  count.Split `json:"line_split"`
+	// Counts maps tokenizer label to tokens in a comparison scan.
+	Counts map[string]int `json:"counts,omitempty"`
+	// SkeletonTokens counts the file's skeleton view with --view skeleton.
+	SkeletonTokens int `json:"skeleton_tokens,omitempty"`
+	// Symbols are the file's declarations with --granularity symbol.
+	Symbols []symbols.Symbol `json:"symbols,omitempty"`
+	// Fit maps context window name to fit status in JSON output.
+	Fit map[string]string `json:"fit,omitempty"`
+}
+
+// AllFileStats returns all counted files sorted by tokens desc.
+func AllFileStats(result *count.Result) []FileStat {
+	if result == nil || len(result.Files) == 0 {
+		return nil
+	}
+	stats := make([]FileStat, 0, len(result.Files))
+	for _, file := range result.Files {
+		pct := 0.0
+		if result.TotalTokens > 0 {
+			pct = (float64(file.Tokens) / float64(result.TotalTokens)) * 100
+		}
+		stats = append(stats, FileStat{
+			Path:           file.Path,
+			Tokens:         file.Tokens,
+			Bytes:          file.Bytes,
+			Lines:          file.Lines,
+			Extension:      file.Extension,
+			Language:       file.Language,
+			Percentage:     pct,
+			TokenSplit:     file.TokenSplit,
+			LineSplit:      file.LineSplit,
+			Counts:         file.Counts,
+			SkeletonTokens: file.SkeletonTokens,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Tokens == stats[j].Tokens {
+			return stats[i].Path < stats[j].Path
+		}
+		return stats[i].Tokens > stats[j].Tokens
+	})
+	return stats
+}
+
+// TopFileStats returns top N file rows and remaining count.
+func TopFileStats(result *count.Result, limit int) ([]FileStat, int) {
+	all := AllFileStats(result)
+	if limit <= 0 || limit >= len(all) {
+		return all, 0
+	}
+	return all[:limit], len(all) - limit
+}
@@ -0,0 +1,169 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+// Fit statuses of a directory or file against a context window.
+const (
+	FitFits      = "fits"
+	FitTight     = "tight"
+	FitOverflows = "overflows"
+)
+
+// tightFraction is the share of a window beyond which little room is left
+// for instructions and the answer.
+const tightFraction = 0.8
+
+// ContextWindow is a model context size to fit counted tokens into.
+type ContextWindow struct {
+	// Name is the model name, or the size as given, such as "200k".
+	Name   string `json:"name"`
+	Tokens int    `json:"tokens"`
+}
+
+// Fit classifies tokens against the window.
+func (w ContextWindow) Fit(tokens int) string {
+	switch {
+	case tokens > w.Tokens:
+		return FitOverflows
+	case float64(tokens) > tightFraction*float64(w.Tokens):
+		return FitTight
+	default:
+		return FitFits
+	}
+}
+
+// FitStat is a directory or file that fits a context window.
+type FitStat struct {
+	Path   string `json:"path"`
+	Tokens int    `json:"tokens"`
+	Fit    string `json:"fit"`
+}
+
+// WindowReport is how the repository fits one context window.
+type WindowReport struct {
+	ContextWindow
+	TotalTokens int    `json:"total_tokens"`
+	Fit         string `json:"fit"`
+	// LargestFitting are the biggest subtrees that fit while their parent
+	// directory does not, largest first.
+	LargestFitting []FitStat `json:"largest_fitting"`
+	// OverflowingFiles are files that do not fit on their own.
+	OverflowingFiles []FitStat `json:"overflowing_files,omitempty"`
+}
+
+// FitWindow reports how result fits w, keeping up to limit entries per
+// list (<= 0 keeps all).
+func FitWindow(result *count.Result, w ContextWindow, limit int) WindowReport {
+	report := WindowReport{
+		ContextWindow: w,
+		TotalTokens:   result.TotalTokens,
+		Fit:           w.Fit(result.TotalTokens),
+	}
+	if report.Fit != FitOverflows {
+		report.LargestFitting = []FitStat{{Path: "./", Tokens: result.TotalTokens, Fit: report.Fit}}
+		return report
+	}
+
+	// A subtree is listed when it fits and its parent directory overflows.
+	// DirectoryTokens is keyed by OS paths.
+	parentOverflows := func(relPath string) bool {
+		return result.DirectoryTokens[filepath.Dir(relPath)] > w.Tokens
+	}
+	for dir, tokens := range result.DirectoryTokens {
+		if dir == "." || tokens <= 0 || tokens > w.Tokens || !parentOverflows(dir) {
+			continue
+		}
+		report.LargestFitting = append(report.LargestFitting, FitStat{Path: normalizeDirectoryPath(dir), Tokens: tokens, Fit: w.Fit(tokens)})
+	}
+	for _, file := range result.Files {
+		if file.Tokens > w.Tokens {
+			report.OverflowingFiles = append(report.OverflowingFiles, FitStat{Path: file.Path, Tokens: file.Tokens, Fit: FitOverflows})
+			continue
+		}
+		if file.Tokens > 0 && parentOverflows(filepath.FromSlash(file.Path)) {
+			report.LargestFitting = append(report.LargestFitting, FitStat{Path: file.Path, Tokens: file.Tokens, Fit: w.Fit(file.Tokens)})
+		}
+	}
+	report.LargestFitting = topFitStats(report.LargestFitting, limit)
+	report.OverflowingFiles = topFitStats(report.OverflowingFiles, limit)
+	return report
+}
+
+// fitMarks maps window name to the fit of tokens, or nil without windows.
+func fitMarks(windows []ContextWindow, tokens int) map[string]string {
+	if len(windows) == 0 {
+		return nil
+	}
+	marks := make(map[string]string, len(windows))
+	for _, w := range windows {
+		marks[w.Name] = w.Fit(tokens)
+	}
+	return marks
+}
+
+// fitLabel renders the fit of tokens for each window, such as
+// "[fits]" or "[gpt-4o: overflows, gpt-4.1: fits]".
+func fitLabel(windows []ContextWindow, tokens int) string {
+	if len(windows) == 1 {
+		return "[" + windows[0].Fit(tokens) + "]"
+	}
+	parts := make([]string, len(windows))
+	for i, w := range windows {
+		parts[i] = w.Name + ": " + w.Fit(tokens)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func renderContextFit(b *strings.Builder, result *count.Result, windows []ContextWindow) {
+	for i, w := range windows {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		report := FitWindow(result, w, defaultTopLimit)
+		b.WriteString(fmt.Sprintf("Context window fit: %s (%s tokens)\n", w.Name, formatInt(w.Tokens)))
+		b.WriteString(fmt.Sprintf("  Repository: %s tokens, %.0f%% of the window [%s]\n",
+			formatInt(report.TotalTokens), windowPercent(report.TotalTokens, w), report.Fit))
+		if report.Fit == FitOverflows {
+			b.WriteString("  Largest subtrees that fit:\n")
+			if len(report.LargestFitting) == 0 {
+				b.WriteString("    (none)\n")
+			}
+			for _, stat := range report.LargestFitting {
+				b.WriteString(fmt.Sprintf("    %-22s %12s tokens (%3.0f%%) [%s]\n", stat.Path, formatInt(stat.Tokens), windowPercent(stat.Tokens, w), stat.Fit))
+			}
+		}
+		if len(report.OverflowingFiles) > 0 {
+			b.WriteString("  Files larger than the window:\n")
+			for _, stat := range report.OverflowingFiles {
+				b.WriteString(fmt.Sprintf("    %-22s %12s tokens (%3.0f%%)\n", stat.Path, formatInt(stat.Tokens), windowPercent(stat.Tokens, w)))
+			}
+		}
+	}
+}
+
+func windowPercent(tokens int, w ContextWindow) float64 {
+	if w.Tokens <= 0 {
+		return 0
+	}
+	return float64(tokens) / float64(w.Tokens) * 100
+}
+
+func topFitStats(stats []FitStat, limit int) []FitStat {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Tokens == stats[j].Tokens {
+			return stats[i].Path < stats[j].Path
+		}
+		return stats[i].Tokens > stats[j].Tokens
+	})
+	if limit > 0 && len(stats) > limit {
+		return stats[:limit]
+	}
+	return stats
+}
@@ -0,0 +1,73 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+func TestFitWindow_LargestFittingSubtrees(t *testing.T) {
+	result := &count.Result{
+		TotalTokens: 300,
+		DirectoryTokens: map[string]int{
+			".":          300,
+			"src":        250,
+			"src/api":    90,
+			"src/api/v1": 60,
+			"src/web":    40,
+			"docs":       50,
+		},
+		Files: []count.FileStat{
+			{Path: "src/api/v1/a.go", Tokens: 60},
+			{Path: "src/api/b.go", Tokens: 30},
+			{Path: "src/web/c.ts", Tokens: 40},
+			{Path: "src/huge.go", Tokens: 120},
+			{Path: "docs/d.md", Tokens: 50},
+		},
+	}
+	w := ContextWindow{Name: "100", Tokens: 100}
+
+	report := FitWindow(result, w, 0)
+	if report.Fit != FitOverflows {
+		t.Fatalf("expected the repository to overflow, got %s", report.Fit)
+	}
+	want := []FitStat{
+		{Path: "src/api/", Tokens: 90, Fit: FitTight},
+		{Path: "docs/", Tokens: 50, Fit: FitFits},
+		{Path: "src/web/", Tokens: 40, Fit: FitFits},
+	}
+	if !reflect.DeepEqual(report.LargestFitting, want) {
+		t.Fatalf("expected %+v, got %+v", want, report.LargestFitting)
+	}
+	if len(report.OverflowingFiles) != 1 || report.OverflowingFiles[0].Path != "src/huge.go" {
+		t.Fatalf("expected src/huge.go to overflow, got %+v", report.OverflowingFiles)
+	}
+
+	whole := FitWindow(result, ContextWindow{Name: "1k", Tokens: 1000}, 0)
+	if whole.Fit != FitFits || len(whole.LargestFitting) != 1 || whole.LargestFitting[0].Path != "./" {
+		t.Fatalf("expected the whole repository to fit, got %+v", whole)
+	}
+}
+
+func TestRenderJSON_IncludesContextWindows(t *testing.T) {
+	result := &count.Result{
+		TotalTokens:     150,
+		DirectoryTokens: map[string]int{".": 150},
+		Files:           []count.FileStat{{Path: "a.go", Tokens: 150}},
+	}
+	raw, err := RenderJSON(result, Options{ContextWindows: []ContextWindow{{Name: "100", Tokens: 100}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		ContextWindows []WindowReport `json:"context_windows"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.ContextWindows) != 1 || payload.ContextWindows[0].Fit != FitOverflows {
+		t.Fatalf("expected one overflowing window report, got %+v", payload.ContextWindows)
+	}
+}
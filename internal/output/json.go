@@ -8,32 +8,80 @@ import (
 )
 
 type jsonPayload struct {
-	Repository      string          `json:"repository"`
-	Tokenizer       string          `json:"tokenizer"`
-	TotalTokens     int             `json:"total_tokens"`
-	TotalFiles      int             `json:"total_files"`
-	IgnoredFiles    int             `json:"ignored_files"`
-	Directories     []DirectoryStat `json:"directories"`
-	PricingEstimate PricingEstimate `json:"pricing_estimate"`
+	Repository     string                       `json:"repository"`
+	Revision       string                       `json:"revision,omitempty"`
+	Tokenizer      string                       `json:"tokenizer"`
+	TotalTokens    int                          `json:"total_tokens"`
+	TotalLines     int                          `json:"total_lines"`
+	TokenSplit     count.Split                  `json:"token_split"`
+	LineSplit      count.Split                  `json:"line_split"`
+	Counts         map[string]int               `json:"counts,omitempty"`
+	Skeleton       *count.SkeletonStat          `json:"skeleton,omitempty"`
+	TotalFiles     int                          `json:"total_files"`
+	IgnoredFiles   int                          `json:"ignored_files"`
+	Ignored        map[string]count.IgnoredStat `json:"ignored"`
+	Directories    []DirectoryStat              `json:"directories"`
+	Languages      []LanguageStat               `json:"languages"`
+	Files          []FileStat                   `json:"files"`
+	ContextWindows []WindowReport               `json:"context_windows,omitempty"`
+	// PricingJSON adds the pricing section's fields after the token counts.
+	PricingJSON
 }
 
 // RenderJSON marshals machine-readable token count output.
-func RenderJSON(result *count.Result) ([]byte, error) {
+func RenderJSON(result *count.Result, opts Options) ([]byte, error) {
 	all := AllDirectoryStats(result)
 	for i := range all {
 		all[i].Percentage = math.Round(all[i].Percentage*10) / 10
+		all[i].Fit = fitMarks(opts.ContextWindows, all[i].Tokens)
+	}
+	files := AllFileStats(result)
+	for i := range files {
+		files[i].Percentage = math.Round(files[i].Percentage*10) / 10
+		files[i].Fit = fitMarks(opts.ContextWindows, files[i].Tokens)
+		files[i].Symbols = opts.Symbols[files[i].Path]
+	}
+	languages := AllLanguageStats(result)
+	for i := range languages {
+		languages[i].Percentage = math.Round(languages[i].Percentage*10) / 10
+	}
+	var windows []WindowReport
+	for _, w := range opts.ContextWindows {
+		windows = append(windows, FitWindow(result, w, 0))
 	}
 
 	payload := jsonPayload{
-		Repository:      result.Repository,
-		Tokenizer:       result.Tokenizer,
-		TotalTokens:     result.TotalTokens,
-		TotalFiles:      result.TotalFiles,
-		IgnoredFiles:    result.IgnoredFiles,
-		Directories:     all,
-		PricingEstimate: EstimatePricing(result.TotalTokens),
-	}
-	payload.PricingEstimate.TokensMillions = math.Round(payload.PricingEstimate.TokensMillions*100) / 100
-
+		Repository:     result.Repository,
+		Revision:       result.Revision,
+		Tokenizer:      result.Tokenizer,
+		TotalTokens:    result.TotalTokens,
+		TotalLines:     result.TotalLines,
+		TokenSplit:     result.TokenSplit,
+		LineSplit:      result.LineSplit,
+		Counts:         totalCounts(result),
+		Skeleton:       result.Skeleton,
+		TotalFiles:     result.TotalFiles,
+		IgnoredFiles:   result.IgnoredFiles,
+		Ignored:        result.Ignored,
+		Directories:    all,
+		Languages:      languages,
+		Files:          files,
+		ContextWindows: windows,
+	}
+	if opts.Pricing != nil {
+		payload.PricingJSON = opts.Pricing.JSON(result)
+	}
 	return json.MarshalIndent(payload, "", "  ")
 }
+
+// totalCounts maps tokenizer label to total tokens in a comparison scan.
+func totalCounts(result *count.Result) map[string]int {
+	if len(result.Comparison) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(result.Comparison))
+	for _, total := range result.Comparison {
+		counts[total.Name] = total.TotalTokens
+	}
+	return counts
+}
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"sort"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+// Breakdowns accepted by Options.By.
+const (
+	ByDirectory = "directory"
+	ByLanguage  = "language"
+)
+
+// LanguageStat is a normalized language-level token rollup.
+type LanguageStat struct {
+	Language   string  `json:"language"`
+	Files      int     `json:"files"`
+	Tokens     int     `json:"tokens"`
+	Lines      int     `json:"lines"`
+	Bytes      int64   `json:"bytes"`
+	Percentage float64 `json:"percentage"`
+}
+
+// AllLanguageStats returns every language sorted by tokens desc.
+func AllLanguageStats(result *count.Result) []LanguageStat {
+	if result == nil || len(result.Languages) == 0 {
+		return nil
+	}
+	stats := make([]LanguageStat, 0, len(result.Languages))
+	for lang, stat := range result.Languages {
+		pct := 0.0
+		if result.TotalTokens > 0 {
+			pct = (float64(stat.Tokens) / float64(result.TotalTokens)) * 100
+		}
+		stats = append(stats, LanguageStat{
+			Language:   lang,
+			Files:      stat.Files,
+			Tokens:     stat.Tokens,
+			Lines:      stat.Lines,
+			Bytes:      stat.Bytes,
+			Percentage: pct,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Tokens == stats[j].Tokens {
+			return stats[i].Language < stats[j].Language
+		}
+		return stats[i].Tokens > stats[j].Tokens
+	})
+	return stats
+}
+
+// TopLanguageStats returns top N language rows and remaining count.
+func TopLanguageStats(result *count.Result, limit int) ([]LanguageStat, int) {
+	all := AllLanguageStats(result)
+	if limit <= 0 || limit >= len(all) {
+		return all, 0
+	}
+	return all[:limit], len(all) - limit
+}
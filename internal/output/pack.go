@@ -0,0 +1,24 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/pack"
+)
+
+// RenderPackReport summarizes a pack. written is the file the pack was
+// saved to, or "" when it went to stdout.
+func RenderPackReport(result *pack.Result, written string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Packed %s of %s files: %s tokens of a %s budget (%s, wrappers included)\n",
+		formatInt(len(result.Files)), formatInt(result.Candidates),
+		formatInt(result.Tokens), formatInt(result.Budget), result.Tokenizer))
+	if result.SkippedFiles > 0 {
+		b.WriteString(fmt.Sprintf("Left out %s files (~%s tokens) that did not fit\n", formatInt(result.SkippedFiles), formatInt(result.SkippedTokens)))
+	}
+	if written != "" && written != "-" {
+		b.WriteString(fmt.Sprintf("Wrote %s\n", written))
+	}
+	return b.String()
+}
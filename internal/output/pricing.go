@@ -0,0 +1,161 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/pricing"
+)
+
+// PricingSection is a pluggable pricing block for the summary and JSON
+// output.
+type PricingSection interface {
+	// Summary returns the block printed after the token breakdown.
+	Summary(result *count.Result) string
+	// JSON returns the top-level fields the section adds to JSON output.
+	JSON(result *count.Result) PricingJSON
+}
+
+// PricingJSON holds the top-level JSON fields of the pricing sections; a
+// section sets its own and leaves the rest empty.
+type PricingJSON struct {
+	PricingEstimate *PricingEstimate `json:"pricing_estimate,omitempty"`
+	ModelCosts      []pricing.Cost   `json:"model_costs,omitempty"`
+}
+
+// Pricing section names accepted by --pricing; the CLI resolves them in
+// pricingFlags.options.
+const (
+	PricingNone    = "none"
+	PricingIntent  = "intent"
+	PricingCatalog = "catalog"
+)
+
+const (
+	proofPilotUSDPerMillion = 20000
+	pricingURL              = "https://intent-systems.com/intent-layer"
+	contactEmail            = "hello@intent-systems.com"
+	pricingDisclaimer       = "Directional estimate only. Ignore patterns are best-effort and repository-specific."
+)
+
+// PricingEstimate represents the Intent Layer estimate block.
+type PricingEstimate struct {
+	TokensMillions        float64 `json:"tokens_millions"`
+	ProofPilotEstimateUSD int     `json:"proof_pilot_estimate_usd"`
+	USDPerMillion         int     `json:"-"`
+	URL                   string  `json:"url"`
+	Disclaimer            string  `json:"disclaimer"`
+	Contact               string  `json:"contact"`
+}
+
+// EstimatePricing computes price estimate from total token count.
+func EstimatePricing(totalTokens int, usdPerMillion int) PricingEstimate {
+	if usdPerMillion <= 0 {
+		usdPerMillion = proofPilotUSDPerMillion
+	}
+	millions := float64(totalTokens) / 1_000_000.0
+	raw := millions * float64(usdPerMillion)
+	rounded := int(math.Round(raw/100.0) * 100.0)
+	return PricingEstimate{
+		TokensMillions:        math.Round(millions*100) / 100,
+		ProofPilotEstimateUSD: rounded,
+		USDPerMillion:         usdPerMillion,
+		URL:                   pricingURL,
+		Disclaimer:            pricingDisclaimer,
+		Contact:               contactEmail,
+	}
+}
+
+// IntentPricing is the Intent Systems Proof Pilot estimate. usdPerMillion
+// <= 0 uses the default rate.
+type IntentPricing struct {
+	USDPerMillion int
+}
+
+// Summary renders the Proof Pilot block.
+func (p IntentPricing) Summary(result *count.Result) string {
+	var b strings.Builder
+	estimate := EstimatePricing(result.TotalTokens, p.USDPerMillion)
+	b.WriteString("---\n")
+	b.WriteString("Intent Systems - Proof Pilot Estimate\n")
+	b.WriteString(fmt.Sprintf("  Tokens mapped: %s (~%.2fM)\n", formatInt(result.TotalTokens), estimate.TokensMillions))
+	b.WriteString(fmt.Sprintf("  Estimated cost: ~$%s ($%s per 1M tokens + onboarding)\n", formatInt(estimate.ProofPilotEstimateUSD), formatUSDShort(estimate.USDPerMillion)))
+	b.WriteString("  Freshness Retainer: $5-10K/month\n")
+	b.WriteString(fmt.Sprintf("  Disclaimer: %s\n", estimate.Disclaimer))
+	b.WriteString(fmt.Sprintf("  For an accurate quote/assessment: %s\n", estimate.Contact))
+	b.WriteString(fmt.Sprintf("  Learn more: %s\n", estimate.URL))
+	return b.String()
+}
+
+// JSON returns the pricing_estimate field.
+func (p IntentPricing) JSON(result *count.Result) PricingJSON {
+	estimate := EstimatePricing(result.TotalTokens, p.USDPerMillion)
+	return PricingJSON{PricingEstimate: &estimate}
+}
+
+// CatalogPricing prices the total tokens as input to catalog models.
+type CatalogPricing struct {
+	Models []pricing.Model
+	// PassesPerDay adds daily and monthly costs when > 0.
+	PassesPerDay int
+}
+
+// Costs prices tokens against every model.
+func (p CatalogPricing) Costs(tokens int) []pricing.Cost {
+	costs := make([]pricing.Cost, 0, len(p.Models))
+	for _, model := range p.Models {
+		costs = append(costs, model.Cost(tokens, p.PassesPerDay))
+	}
+	return costs
+}
+
+// Summary renders a cost table with one row per model.
+func (p CatalogPricing) Summary(result *count.Result) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Model cost of one full-context pass (%s input tokens):\n", formatInt(result.TotalTokens)))
+	perDay := p.PassesPerDay > 0
+	header := fmt.Sprintf("  %-20s %12s %12s", "model", "per pass", "cached")
+	if perDay {
+		header += fmt.Sprintf(" %14s %12s", fmt.Sprintf("per day (x%d)", p.PassesPerDay), "per month")
+	}
+	b.WriteString(header + "\n")
+	for _, cost := range p.Costs(result.TotalTokens) {
+		cached := "-"
+		if cost.CachedPassUSD > 0 {
+			cached = formatUSD(cost.CachedPassUSD)
+		}
+		line := fmt.Sprintf("  %-20s %12s %12s", cost.Model, formatUSD(cost.PassUSD), cached)
+		if perDay {
+			line += fmt.Sprintf(" %14s %12s", formatUSD(cost.DailyUSD), formatUSD(cost.MonthlyUSD))
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// JSON returns the model_costs field, rounded to a hundredth of a cent.
+func (p CatalogPricing) JSON(result *count.Result) PricingJSON {
+	costs := p.Costs(result.TotalTokens)
+	for i := range costs {
+		costs[i].PassUSD = roundUSD(costs[i].PassUSD)
+		costs[i].CachedPassUSD = roundUSD(costs[i].CachedPassUSD)
+		costs[i].DailyUSD = roundUSD(costs[i].DailyUSD)
+		costs[i].MonthlyUSD = roundUSD(costs[i].MonthlyUSD)
+	}
+	return PricingJSON{ModelCosts: costs}
+}
+
+// formatUSD renders dollars with cents, or four decimals below a cent.
+func formatUSD(v float64) string {
+	if v > 0 && v < 0.01 {
+		return fmt.Sprintf("$%.4f", v)
+	}
+	cents := int(math.Round(v * 100))
+	return fmt.Sprintf("$%s.%02d", formatInt(cents/100), cents%100)
+}
+
+func roundUSD(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/pricing"
+)
+
+func TestPricingSections(t *testing.T) {
+	result := &count.Result{
+		TotalTokens:     2_000_000,
+		TotalFiles:      1,
+		DirectoryTokens: map[string]int{".": 2_000_000},
+		Files:           []count.FileStat{{Path: "a.go", Tokens: 2_000_000}},
+	}
+	catalog := CatalogPricing{Models: []pricing.Model{{Name: "m", Input: 3}}}
+
+	cases := []struct {
+		name        string
+		section     PricingSection
+		field       string
+		summaryText string
+	}{
+		{"none", nil, "", ""},
+		{"intent", IntentPricing{}, "pricing_estimate", "Proof Pilot Estimate"},
+		{"catalog", catalog, "model_costs", "$6.00"},
+	}
+	for _, tc := range cases {
+		opts := Options{Pricing: tc.section}
+
+		raw, err := RenderJSON(result, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var payload map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tc.name, err)
+		}
+		for _, field := range []string{"pricing_estimate", "model_costs"} {
+			if _, ok := payload[field]; ok != (field == tc.field) {
+				t.Fatalf("%s: unexpected presence of %s in %s", tc.name, field, raw)
+			}
+		}
+
+		summary := RenderSummary(result, opts)
+		if tc.summaryText != "" && !strings.Contains(summary, tc.summaryText) {
+			t.Fatalf("%s: expected %q in summary:\n%s", tc.name, tc.summaryText, summary)
+		}
+		if tc.section == nil && (strings.Contains(summary, "Intent Systems") || strings.Contains(summary, "---")) {
+			t.Fatalf("none: expected no pricing block:\n%s", summary)
+		}
+	}
+}
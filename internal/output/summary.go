@@ -2,63 +2,83 @@ package output
 
 import (
 	"fmt"
-	"math"
 	"strings"
 
 	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/symbols"
 )
 
-const (
-	proofPilotUSDPerMillion = 20000
-	pricingURL              = "https://intent-systems.com/intent-layer"
-	contactEmail            = "hello@intent-systems.com"
-	pricingDisclaimer       = "Directional estimate only. Ignore patterns are best-effort and repository-specific."
-	defaultTopLimit         = 10
-)
-
-// PricingEstimate represents the Intent Layer estimate block.
-type PricingEstimate struct {
-	TokensMillions        float64 `json:"tokens_millions"`
-	ProofPilotEstimateUSD int     `json:"proof_pilot_estimate_usd"`
-	URL                   string  `json:"url"`
-	Disclaimer            string  `json:"disclaimer"`
-	Contact               string  `json:"contact"`
-}
+const defaultTopLimit = 10
 
-// EstimatePricing computes price estimate from total token count.
-func EstimatePricing(totalTokens int) PricingEstimate {
-	millions := float64(totalTokens) / 1_000_000.0
-	raw := millions * proofPilotUSDPerMillion
-	rounded := int(math.Round(raw/100.0) * 100.0)
-	return PricingEstimate{
-		TokensMillions:        math.Round(millions*100) / 100,
-		ProofPilotEstimateUSD: rounded,
-		URL:                   pricingURL,
-		Disclaimer:            pricingDisclaimer,
-		Contact:               contactEmail,
-	}
+// Options tunes rendering.
+type Options struct {
+	// Pricing adds a pricing block to the summary and JSON output; nil
+	// leaves it out.
+	Pricing PricingSection
+	// By picks the summary and tree breakdown: ByDirectory (default) or
+	// ByLanguage.
+	By string
+	// Symbols lists declarations by file path; when set, the tree shows them
+	// under each file and JSON output adds them to every file.
+	Symbols map[string][]symbols.Symbol
+	// ContextWindows adds a fit report and marks the tree, directories and
+	// files as fits, tight, or overflows for each window.
+	ContextWindows []ContextWindow
 }
 
 // RenderSummary returns human-readable default CLI output.
-func RenderSummary(result *count.Result) string {
+func RenderSummary(result *count.Result, opts Options) string {
 	var b strings.Builder
-	pricing := EstimatePricing(result.TotalTokens)
 	top, remaining := TopDirectoryStats(result, defaultTopLimit)
+	topFiles, remainingFiles := TopFileStats(result, defaultTopLimit)
 
 	b.WriteString(fmt.Sprintf("Repository: %s\n", result.Repository))
+	if result.Revision != "" {
+		b.WriteString(fmt.Sprintf("Revision: %s\n", result.Revision))
+	}
 	b.WriteString(fmt.Sprintf("Tokenizer: %s\n", result.TokenizerDetail))
 	b.WriteString(fmt.Sprintf("Files scanned: %s\n", formatInt(result.TotalFiles)))
 	b.WriteString(fmt.Sprintf("Files ignored: %s\n", formatInt(result.IgnoredFiles)))
+	for _, reason := range count.IgnoreReasons {
+		stat, ok := result.Ignored[reason]
+		if !ok || stat.Files == 0 {
+			continue
+		}
+		line := fmt.Sprintf("  %-14s %10s files %10s", reason, formatInt(stat.Files), formatBytes(stat.Bytes))
+		if stat.EstimatedTokens > 0 {
+			line += fmt.Sprintf("  ~%s tokens", formatInt(stat.EstimatedTokens))
+		}
+		b.WriteString(line + "\n")
+	}
 	b.WriteString("\n")
 	b.WriteString(fmt.Sprintf("Total: %s tokens (~%s lines)\n", formatInt(result.TotalTokens), formatInt(result.TotalLines)))
+	renderSplit(&b, result)
+	if result.Skeleton != nil {
+		b.WriteString(fmt.Sprintf("Skeleton: %s tokens (%s), bodies elided in %s files\n",
+			formatInt(result.Skeleton.Tokens), skeletonShare(result), formatInt(result.Skeleton.Files)))
+	}
+	if len(result.Comparison) > 0 {
+		renderComparisonTotals(&b, result)
+	}
 	b.WriteString("\n")
 
-	b.WriteString("Top token contributors (directories):\n")
-	if len(top) == 0 {
-		b.WriteString("  (no directories with counted files)\n")
+	if opts.By == ByLanguage {
+		renderLanguages(&b, result)
 	} else {
-		for _, row := range top {
-			b.WriteString(fmt.Sprintf("  %-22s %12s tokens (%2.0f%%)\n", row.Path, formatInt(row.Tokens), row.Percentage))
+		b.WriteString("Top token contributors (directories):\n")
+		if len(top) == 0 {
+			b.WriteString("  (no directories with counted files)\n")
+		} else if len(result.Comparison) > 0 {
+			paths := make([]string, len(top))
+			counts := make([]map[string]int, len(top))
+			for i, row := range top {
+				paths[i], counts[i] = row.Path, row.Counts
+			}
+			renderComparisonRows(&b, result, paths, counts)
+		} else {
+			for _, row := range top {
+				b.WriteString(fmt.Sprintf("  %-22s %12s tokens (%2.0f%%)\n", row.Path, formatInt(row.Tokens), row.Percentage))
+			}
 		}
 		if remaining > 0 {
 			b.WriteString(fmt.Sprintf("  ... %d more directories\n", remaining))
@@ -66,18 +86,110 @@ func RenderSummary(result *count.Result) string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString("---\n")
-	b.WriteString("Intent Systems - Proof Pilot Estimate\n")
-	b.WriteString(fmt.Sprintf("  Tokens mapped: %s (~%.2fM)\n", formatInt(result.TotalTokens), pricing.TokensMillions))
-	b.WriteString(fmt.Sprintf("  Estimated cost: ~$%s ($20K per 1M tokens + onboarding)\n", formatInt(pricing.ProofPilotEstimateUSD)))
-	b.WriteString("  Freshness Retainer: $5-10K/month\n")
-	b.WriteString(fmt.Sprintf("  Disclaimer: %s\n", pricing.Disclaimer))
-	b.WriteString(fmt.Sprintf("  For an accurate quote/assessment: %s\n", pricing.Contact))
-	b.WriteString(fmt.Sprintf("  Learn more: %s\n", pricing.URL))
+	b.WriteString("Top token contributors (files):\n")
+	if len(topFiles) == 0 {
+		b.WriteString("  (no counted files)\n")
+	} else if len(result.Comparison) > 0 {
+		paths := make([]string, len(topFiles))
+		counts := make([]map[string]int, len(topFiles))
+		for i, row := range topFiles {
+			paths[i], counts[i] = row.Path, row.Counts
+		}
+		renderComparisonRows(&b, result, paths, counts)
+	} else {
+		for _, row := range topFiles {
+			b.WriteString(fmt.Sprintf("  %-22s %12s tokens (%2.0f%%)\n", row.Path, formatInt(row.Tokens), row.Percentage))
+		}
+	}
+	if remainingFiles > 0 {
+		b.WriteString(fmt.Sprintf("  ... %d more files\n", remainingFiles))
+	}
+
+	if len(opts.ContextWindows) > 0 {
+		b.WriteString("\n")
+		renderContextFit(&b, result, opts.ContextWindows)
+	}
+
+	if opts.Pricing != nil {
+		if section := opts.Pricing.Summary(result); section != "" {
+			b.WriteString("\n")
+			b.WriteString(section)
+		}
+	}
 
 	return b.String()
 }
 
+// skeletonShare renders the skeleton view's share of the full count.
+func skeletonShare(result *count.Result) string {
+	if result.TotalTokens == 0 {
+		return "no tokens in full"
+	}
+	return fmt.Sprintf("%.1f%% of %s in full", float64(result.Skeleton.Tokens)/float64(result.TotalTokens)*100, formatInt(result.TotalTokens))
+}
+
+// renderSplit breaks the total down into code, comments, docstrings and
+// blank lines.
+func renderSplit(b *strings.Builder, result *count.Result) {
+	if result.TotalLines == 0 {
+		return
+	}
+	rows := []struct {
+		label         string
+		tokens, lines int
+	}{
+		{"code", result.TokenSplit.Code, result.LineSplit.Code},
+		{"comments", result.TokenSplit.Comment, result.LineSplit.Comment},
+		{"docstrings", result.TokenSplit.Docstring, result.LineSplit.Docstring},
+		{"blank", result.TokenSplit.Blank, result.LineSplit.Blank},
+	}
+	for _, row := range rows {
+		pct := 0.0
+		if result.TotalTokens > 0 {
+			pct = float64(row.tokens) / float64(result.TotalTokens) * 100
+		}
+		b.WriteString(fmt.Sprintf("  %-12s %12s tokens (%2.0f%%) %10s lines\n", row.label, formatInt(row.tokens), pct, formatInt(row.lines)))
+	}
+}
+
+func renderLanguages(b *strings.Builder, result *count.Result) {
+	top, remaining := TopLanguageStats(result, defaultTopLimit)
+	b.WriteString("Top token contributors (languages):\n")
+	if len(top) == 0 {
+		b.WriteString("  (no counted files)\n")
+		return
+	}
+	for _, row := range top {
+		b.WriteString(fmt.Sprintf("  %-22s %12s tokens (%2.0f%%) %8s files %10s lines\n",
+			row.Language, formatInt(row.Tokens), row.Percentage, formatInt(row.Files), formatInt(row.Lines)))
+	}
+	if remaining > 0 {
+		b.WriteString(fmt.Sprintf("  ... %d more languages\n", remaining))
+	}
+}
+
+// formatBytes renders a byte count with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatUSDShort renders whole thousands as "20K" and anything else in full.
+func formatUSDShort(v int) string {
+	if v >= 1000 && v%1000 == 0 {
+		return fmt.Sprintf("%dK", v/1000)
+	}
+	return formatInt(v)
+}
+
 func formatInt(v int) string {
 	if v == 0 {
 		return "0"
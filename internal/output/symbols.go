@@ -0,0 +1,39 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/symbols"
+)
+
+// RenderSymbols lists symbols in the result's order, up to limit rows
+// (0 for all).
+func RenderSymbols(result *symbols.Result, limit int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Tokenizer: %s\n", result.Tokenizer))
+	b.WriteString(fmt.Sprintf("Symbols: %s in %s files, %s tokens\n",
+		formatInt(len(result.Symbols)), formatInt(result.Files), formatInt(result.Tokens)))
+	if len(result.Unparsed) > 0 {
+		b.WriteString(fmt.Sprintf("Could not parse %s files: %s\n", formatInt(len(result.Unparsed)), strings.Join(result.Unparsed, ", ")))
+	}
+	b.WriteString("\n")
+
+	if len(result.Symbols) == 0 {
+		b.WriteString("  (no symbols)\n")
+		return b.String()
+	}
+	rows := result.Symbols
+	if limit > 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	b.WriteString(fmt.Sprintf("  %10s %6s  %-9s %-32s %s\n", "tokens", "lines", "kind", "symbol", "location"))
+	for _, sym := range rows {
+		b.WriteString(fmt.Sprintf("  %10s %6s  %-9s %-32s %s\n",
+			formatInt(sym.Tokens), formatInt(sym.Lines()), sym.Kind, sym.Name, sym.Location()))
+	}
+	if remaining := len(result.Symbols) - len(rows); remaining > 0 {
+		b.WriteString(fmt.Sprintf("  ... %d more symbols\n", remaining))
+	}
+	return b.String()
+}
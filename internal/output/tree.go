@@ -7,17 +7,28 @@ import (
 	"strings"
 
 	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/symbols"
 )
 
 type treeNode struct {
-	name     string
-	path     string
-	tokens   int
+	name   string
+	path   string
+	tokens int
+	isFile bool
+	// isGroup marks a language heading in a by-language tree.
+	isGroup bool
+	// isSymbol marks a declaration under a file.
+	isSymbol bool
 	children map[string]*treeNode
 }
 
-// RenderTree returns an ASCII full directory token breakdown.
-func RenderTree(result *count.Result) string {
+// RenderTree returns an ASCII full directory token breakdown, or files
+// grouped by language when opts.By is ByLanguage. Each line is marked with
+// its fit when opts has context windows.
+func RenderTree(result *count.Result, opts Options) string {
+	if opts.By == ByLanguage {
+		return renderLanguageTree(result, opts)
+	}
 	root := &treeNode{
 		name:     ".",
 		path:     ".",
@@ -31,19 +42,72 @@ func RenderTree(result *count.Result) string {
 		}
 		insertTreeNode(root, relPath, tokens)
 	}
+	for _, file := range result.Files {
+		if file.Tokens <= 0 {
+			continue
+		}
+		node := insertTreeNode(root, file.Path, file.Tokens)
+		node.isFile = true
+		attachSymbols(node, opts.Symbols[file.Path])
+	}
 
 	var b strings.Builder
 	b.WriteString("Directory tree:\n")
-	b.WriteString(fmt.Sprintf(".  %s tokens (100.0%%)\n", formatInt(result.TotalTokens)))
+	renderTreeRoot(&b, root, result.TotalTokens, opts.ContextWindows)
+	return b.String()
+}
+
+// renderLanguageTree lists each language with its files beneath it.
+func renderLanguageTree(result *count.Result, opts Options) string {
+	root := &treeNode{
+		name:     ".",
+		path:     ".",
+		tokens:   result.TotalTokens,
+		children: make(map[string]*treeNode),
+	}
+	for _, file := range result.Files {
+		if file.Tokens <= 0 {
+			continue
+		}
+		lang := file.Language
+		group := root.children[lang]
+		if group == nil {
+			group = &treeNode{
+				name:     lang,
+				path:     lang,
+				tokens:   result.Languages[lang].Tokens,
+				isGroup:  true,
+				children: make(map[string]*treeNode),
+			}
+			root.children[lang] = group
+		}
+		node := &treeNode{
+			name:     file.Path,
+			path:     file.Path,
+			tokens:   file.Tokens,
+			isFile:   true,
+			children: make(map[string]*treeNode),
+		}
+		attachSymbols(node, opts.Symbols[file.Path])
+		group.children[file.Path] = node
+	}
+
+	var b strings.Builder
+	b.WriteString("Language tree:\n")
+	renderTreeRoot(&b, root, result.TotalTokens, opts.ContextWindows)
+	return b.String()
+}
+
+func renderTreeRoot(b *strings.Builder, root *treeNode, totalTokens int, windows []ContextWindow) {
+	b.WriteString(fmt.Sprintf(".  %s tokens (100.0%%)%s\n", formatInt(totalTokens), treeFitLabel(windows, totalTokens)))
 
 	children := sortedChildren(root)
 	for i, child := range children {
-		renderTreeNode(&b, child, "", i == len(children)-1, result.TotalTokens)
+		renderTreeNode(b, child, "", i == len(children)-1, totalTokens, windows)
 	}
-	return b.String()
 }
 
-func insertTreeNode(root *treeNode, relPath string, tokens int) {
+func insertTreeNode(root *treeNode, relPath string, tokens int) *treeNode {
 	parts := strings.Split(filepath.ToSlash(relPath), "/")
 	current := root
 	currentPath := ""
@@ -71,9 +135,10 @@ func insertTreeNode(root *treeNode, relPath string, tokens int) {
 	}
 
 	current.tokens = tokens
+	return current
 }
 
-func renderTreeNode(b *strings.Builder, node *treeNode, prefix string, isLast bool, totalTokens int) {
+func renderTreeNode(b *strings.Builder, node *treeNode, prefix string, isLast bool, totalTokens int, windows []ContextWindow) {
 	branch := "|- "
 	childPrefix := prefix + "|  "
 	if isLast {
@@ -86,18 +151,61 @@ func renderTreeNode(b *strings.Builder, node *treeNode, prefix string, isLast bo
 		percent = (float64(node.tokens) / float64(totalTokens)) * 100
 	}
 
-	b.WriteString(fmt.Sprintf("%s%s%s/ %s tokens (%.1f%%)\n",
+	name := node.name + "/"
+	if node.isFile || node.isGroup || node.isSymbol {
+		name = node.name
+	}
+
+	b.WriteString(fmt.Sprintf("%s%s%s %s tokens (%.1f%%)%s\n",
 		prefix,
 		branch,
-		node.name,
+		name,
 		formatInt(node.tokens),
 		percent,
+		treeFitLabel(windows, node.tokens),
 	))
 
 	children := sortedChildren(node)
 	for i, child := range children {
-		renderTreeNode(b, child, childPrefix, i == len(children)-1, totalTokens)
+		renderTreeNode(b, child, childPrefix, i == len(children)-1, totalTokens, windows)
+	}
+}
+
+// attachSymbols hangs a file's declarations under it, nested ones under
+// the parent whose lines enclose them.
+func attachSymbols(file *treeNode, syms []symbols.Symbol) {
+	nodes := make([]*treeNode, len(syms))
+	for i, sym := range syms {
+		name := sym.Name
+		if sym.Parent != "" {
+			name = name[strings.LastIndexAny(name, ".:")+1:]
+		}
+		nodes[i] = &treeNode{
+			name:     fmt.Sprintf("%s %s [%d-%d]", sym.Kind, name, sym.StartLine, sym.EndLine),
+			path:     fmt.Sprintf("%s:%d:%s", file.path, sym.StartLine, sym.Name),
+			tokens:   sym.Tokens,
+			isSymbol: true,
+			children: make(map[string]*treeNode),
+		}
+	}
+	for i, sym := range syms {
+		parent := file
+		for j, candidate := range syms {
+			if sym.Parent != "" && candidate.Name == sym.Parent && j != i &&
+				candidate.StartLine <= sym.StartLine && sym.EndLine <= candidate.EndLine {
+				parent = nodes[j]
+				break
+			}
+		}
+		parent.children[nodes[i].path] = nodes[i]
+	}
+}
+
+func treeFitLabel(windows []ContextWindow, tokens int) string {
+	if len(windows) == 0 {
+		return ""
 	}
+	return " " + fitLabel(windows, tokens)
 }
 
 func sortedChildren(node *treeNode) []*treeNode {
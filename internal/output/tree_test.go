@@ -0,0 +1,34 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/symbols"
+)
+
+func TestRenderTree_ListsSymbolsUnderFiles(t *testing.T) {
+	result := &count.Result{
+		TotalTokens:     100,
+		DirectoryTokens: map[string]int{".": 100, "src": 100},
+		Files:           []count.FileStat{{Path: "src/a.rs", Tokens: 100}},
+	}
+	tree := RenderTree(result, Options{Symbols: map[string][]symbols.Symbol{
+		"src/a.rs": {
+			{Name: "Point", Kind: symbols.KindStruct, StartLine: 1, EndLine: 1, Tokens: 10},
+			{Name: "Point", Kind: symbols.KindImpl, StartLine: 3, EndLine: 6, Tokens: 60},
+			{Name: "Point::new", Kind: symbols.KindMethod, Parent: "Point", StartLine: 4, EndLine: 5, Tokens: 40},
+		},
+	}})
+
+	want := strings.Join([]string{
+		"   \\- a.rs 100 tokens (100.0%)",
+		"      |- impl Point [3-6] 60 tokens (60.0%)",
+		"      |  \\- method new [4-5] 40 tokens (40.0%)",
+		"      \\- struct Point [1-1] 10 tokens (10.0%)",
+	}, "\n")
+	if !strings.Contains(tree, want) {
+		t.Fatalf("expected symbols nested under their file and impl, got\n%s", tree)
+	}
+}
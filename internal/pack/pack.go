@@ -0,0 +1,275 @@
+// Package pack bundles repository files into a single prompt under a token
+// budget.
+package pack
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// Output formats.
+const (
+	FormatXML      = "xml"
+	FormatMarkdown = "markdown"
+)
+
+// Strategies order the candidates that compete for the budget.
+const (
+	StrategySmallest = "smallest"
+	StrategyRecent   = "recent"
+)
+
+// Options controls a pack.
+type Options struct {
+	// Files are the candidates, typically count.Result.Files of a scan.
+	Files []count.FileStat
+	// Read returns the content of a candidate by its slash path.
+	Read      func(path string) ([]byte, error)
+	Tokenizer tokenizer.Tokenizer
+	// Budget is the most tokens the packed output may hold.
+	Budget int
+	// Format is FormatXML (default) or FormatMarkdown.
+	Format string
+	// Strategy is StrategySmallest (default) or StrategyRecent.
+	Strategy string
+	// Priority globs (gitignore syntax) rank matching files ahead of the
+	// rest, in the order given; Strategy orders files within a rank.
+	Priority []string
+	// ChangedAt reports when a file last changed, for StrategyRecent.
+	ChangedAt func(path string) time.Time
+}
+
+// Result is the packed output and what went into it.
+type Result struct {
+	Output string `json:"-"`
+	// Tokens is the count of Output, wrappers included.
+	Tokens        int          `json:"tokens"`
+	Budget        int          `json:"budget"`
+	Tokenizer     string       `json:"tokenizer"`
+	Files         []PackedFile `json:"files"`
+	Candidates    int          `json:"candidates"`
+	SkippedFiles  int          `json:"skipped_files"`
+	SkippedTokens int          `json:"skipped_tokens"`
+}
+
+// PackedFile is one file in the output.
+type PackedFile struct {
+	Path string `json:"path"`
+	// Tokens is the count of the file's section, wrapper included.
+	Tokens int `json:"tokens"`
+}
+
+type section struct {
+	stat   count.FileStat
+	text   string
+	tokens int
+}
+
+// Run selects files in priority order while their sections fit the budget,
+// then renders them in path order.
+func Run(opts Options) (*Result, error) {
+	if opts.Tokenizer == nil || opts.Read == nil {
+		return nil, fmt.Errorf("pack: tokenizer and reader are required")
+	}
+	if opts.Budget <= 0 {
+		return nil, fmt.Errorf("pack: budget must be positive")
+	}
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	switch format {
+	case "":
+		format = FormatXML
+	case FormatXML, FormatMarkdown, "md":
+		if format == "md" {
+			format = FormatMarkdown
+		}
+	default:
+		return nil, fmt.Errorf("unsupported pack format: %s (use: xml or markdown)", opts.Format)
+	}
+
+	candidates, err := order(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Budget:     opts.Budget,
+		Tokenizer:  opts.Tokenizer.Description(),
+		Candidates: len(candidates),
+	}
+	var chosen []section
+	used := 0
+	for _, stat := range candidates {
+		// A section costs at least its content; skip reading files that
+		// cannot fit.
+		if stat.Tokens > opts.Budget-used {
+			result.SkippedFiles++
+			result.SkippedTokens += stat.Tokens
+			continue
+		}
+		data, err := opts.Read(stat.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", stat.Path, err)
+		}
+		text := Render(format, stat.Path, string(data))
+		// Count the section as the scan counted the file, so the skip
+		// above and the budget agree for path-dependent tokenizers.
+		tokens := tokenizer.CountFile(opts.Tokenizer, stat.Path, text)
+		if used+tokens > opts.Budget {
+			result.SkippedFiles++
+			result.SkippedTokens += stat.Tokens
+			continue
+		}
+		used += tokens
+		chosen = append(chosen, section{stat: stat, text: text, tokens: tokens})
+	}
+
+	// Tokens can merge across section boundaries, so count the joined
+	// output and, while it is over, drop the last chosen sections by their
+	// own counts before counting it again. Path-dependent tokenizers count
+	// the output as the sum of its sections.
+	_, byPath := opts.Tokenizer.(tokenizer.PathCounter)
+	for {
+		result.Output, result.Tokens = join(chosen), used
+		if !byPath {
+			result.Tokens = opts.Tokenizer.Count(result.Output)
+		}
+		if result.Tokens <= opts.Budget || len(chosen) == 0 {
+			break
+		}
+		for over := result.Tokens; over > opts.Budget && len(chosen) > 0; {
+			last := chosen[len(chosen)-1]
+			chosen = chosen[:len(chosen)-1]
+			over -= last.tokens
+			used -= last.tokens
+			result.SkippedFiles++
+			result.SkippedTokens += last.stat.Tokens
+		}
+	}
+	for _, s := range sortedByPath(chosen) {
+		result.Files = append(result.Files, PackedFile{Path: s.stat.Path, Tokens: s.tokens})
+	}
+	return result, nil
+}
+
+// order ranks candidates by priority glob, then by strategy, then by path.
+func order(opts Options) ([]count.FileStat, error) {
+	matchers := make([]*gitignore.GitIgnore, len(opts.Priority))
+	for i, glob := range opts.Priority {
+		matchers[i] = gitignore.CompileIgnoreLines(glob)
+	}
+	rank := func(p string) int {
+		for i, m := range matchers {
+			if m.MatchesPath(p) {
+				return i
+			}
+		}
+		return len(matchers)
+	}
+
+	strategy := strings.ToLower(strings.TrimSpace(opts.Strategy))
+	switch strategy {
+	case "", StrategySmallest:
+		strategy = StrategySmallest
+	case StrategyRecent:
+		if opts.ChangedAt == nil {
+			return nil, fmt.Errorf("pack: the recent strategy needs change times")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported pack strategy: %s (use: smallest or recent)", opts.Strategy)
+	}
+
+	type ranked struct {
+		stat    count.FileStat
+		rank    int
+		changed time.Time
+	}
+	files := make([]ranked, 0, len(opts.Files))
+	for _, stat := range opts.Files {
+		r := ranked{stat: stat, rank: rank(stat.Path)}
+		if strategy == StrategyRecent {
+			r.changed = opts.ChangedAt(stat.Path)
+		}
+		files = append(files, r)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if strategy == StrategyRecent && !a.changed.Equal(b.changed) {
+			return a.changed.After(b.changed)
+		}
+		if strategy == StrategySmallest && a.stat.Tokens != b.stat.Tokens {
+			return a.stat.Tokens < b.stat.Tokens
+		}
+		return a.stat.Path < b.stat.Path
+	})
+
+	out := make([]count.FileStat, len(files))
+	for i, f := range files {
+		out[i] = f.stat
+	}
+	return out, nil
+}
+
+func join(chosen []section) string {
+	var b strings.Builder
+	for _, s := range sortedByPath(chosen) {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+func sortedByPath(chosen []section) []section {
+	sorted := append([]section(nil), chosen...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].stat.Path < sorted[j].stat.Path })
+	return sorted
+}
+
+// Render wraps one file as an XML element (FormatXML) or a fenced markdown
+// section (FormatMarkdown). XML content is not escaped, so the section
+// matches the file byte for byte; a file containing "</file>" closes it
+// early. Markdown fences outgrow every backtick run in content.
+func Render(format string, relPath string, content string) string {
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if format == FormatMarkdown {
+		fence := markdownFence(content)
+		lang := strings.TrimPrefix(path.Ext(relPath), ".")
+		return fmt.Sprintf("## %s\n\n%s%s\n%s%s\n\n", relPath, fence, lang, content, fence)
+	}
+	return fmt.Sprintf("<file path=\"%s\">\n%s</file>\n\n", xmlAttr(relPath), content)
+}
+
+// markdownFence is a backtick run longer than any in content.
+func markdownFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+var xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func xmlAttr(s string) string {
+	return xmlAttrEscaper.Replace(s)
+}
@@ -0,0 +1,115 @@
+package pack
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+)
+
+// byteTokenizer counts one token per byte, so wrapper costs are exact.
+type byteTokenizer struct{}
+
+func (byteTokenizer) Count(text string) int { return len(text) }
+func (byteTokenizer) Name() string          { return "bytes" }
+func (byteTokenizer) Description() string   { return "bytes (test)" }
+
+func fixture() ([]count.FileStat, func(string) ([]byte, error)) {
+	contents := map[string]string{
+		"a.go":      strings.Repeat("a", 10),
+		"b.go":      strings.Repeat("b", 40),
+		"docs/c.md": strings.Repeat("c", 30),
+		"big.txt":   strings.Repeat("x", 500),
+	}
+	var files []count.FileStat
+	for path, content := range contents {
+		files = append(files, count.FileStat{Path: path, Tokens: len(content), Bytes: int64(len(content))})
+	}
+	return files, func(path string) ([]byte, error) { return []byte(contents[path]), nil }
+}
+
+func TestRun_SmallestFirstUnderBudget(t *testing.T) {
+	files, read := fixture()
+	result, err := Run(Options{Files: files, Read: read, Tokenizer: byteTokenizer{}, Budget: 120})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if result.Tokens != len(result.Output) || result.Tokens > 120 {
+		t.Fatalf("expected the reported count to be the output's and within budget, got %d for %d bytes", result.Tokens, len(result.Output))
+	}
+	var paths []string
+	for _, file := range result.Files {
+		paths = append(paths, file.Path)
+	}
+	if got := strings.Join(paths, ","); got != "a.go,docs/c.md" {
+		t.Fatalf("expected the two smallest files, got %s", got)
+	}
+	if result.SkippedFiles != 2 || result.Candidates != 4 {
+		t.Fatalf("expected 2 of 4 files left out, got %+v", result)
+	}
+	if !strings.HasPrefix(result.Output, "<file path=\"a.go\">\naaaaaaaaaa\n</file>\n") {
+		t.Fatalf("unexpected XML output:\n%s", result.Output)
+	}
+}
+
+func TestRun_PriorityAndRecent(t *testing.T) {
+	files, read := fixture()
+	changed := map[string]time.Time{"b.go": time.Unix(300, 0), "a.go": time.Unix(100, 0), "docs/c.md": time.Unix(200, 0)}
+
+	result, err := Run(Options{
+		Files:     files,
+		Read:      read,
+		Tokenizer: byteTokenizer{},
+		Budget:    120,
+		Strategy:  StrategyRecent,
+		Priority:  []string{"docs/"},
+		ChangedAt: func(path string) time.Time { return changed[path] },
+		Format:    FormatMarkdown,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, file := range result.Files {
+		paths = append(paths, file.Path)
+	}
+	if got := strings.Join(paths, ","); got != "b.go,docs/c.md" {
+		t.Fatalf("expected docs first, then the most recent file, got %s", got)
+	}
+	if !strings.Contains(result.Output, "## b.go\n\n```go\n") {
+		t.Fatalf("expected a fenced markdown section, got:\n%s", result.Output)
+	}
+}
+
+func TestRun_EstimateCountsSectionsByPath(t *testing.T) {
+	content := strings.Repeat(`{"key": "value", "list": [1, 2, 3]}`+"\n", 40)
+	tok := tokenizer.NewEstimate(0)
+	files := []count.FileStat{{Path: "data.json", Tokens: tokenizer.CountFile(tok, "data.json", content)}}
+	read := func(string) ([]byte, error) { return []byte(content), nil }
+	section := tokenizer.CountFile(tok, "data.json", Render(FormatXML, "data.json", content))
+
+	result, err := Run(Options{Files: files, Read: read, Tokenizer: tok, Budget: section})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Tokens != section {
+		t.Fatalf("expected data.json packed at %d tokens, got %d files and %d tokens", section, len(result.Files), result.Tokens)
+	}
+
+	result, err = Run(Options{Files: files, Read: read, Tokenizer: tok, Budget: section - 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 0 || result.SkippedFiles != 1 {
+		t.Fatalf("expected data.json skipped one token under its section, got %+v", result)
+	}
+}
+
+func TestMarkdownFence_LongerThanContent(t *testing.T) {
+	if got := markdownFence("text with ```` inside"); got != "`````" {
+		t.Fatalf("expected a five-backtick fence, got %q", got)
+	}
+}
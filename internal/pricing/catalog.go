@@ -0,0 +1,151 @@
+// Package pricing prices a token count against model list prices.
+package pricing
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// daysPerMonth converts daily cost to a monthly figure.
+const daysPerMonth = 30
+
+// Model is the list price of one model in USD per 1M tokens, and the size
+// of its context window in tokens.
+type Model struct {
+	Name          string  `yaml:"-" json:"name"`
+	Input         float64 `yaml:"input" json:"input"`
+	Output        float64 `yaml:"output,omitempty" json:"output,omitempty"`
+	CacheRead     float64 `yaml:"cache_read,omitempty" json:"cache_read,omitempty"`
+	CacheWrite    float64 `yaml:"cache_write,omitempty" json:"cache_write,omitempty"`
+	ContextWindow int     `yaml:"context_window,omitempty" json:"context_window,omitempty"`
+}
+
+// Catalog maps model names to prices and context windows.
+type Catalog struct {
+	Models map[string]Model `yaml:"models" json:"models"`
+}
+
+// builtinModels are public list prices for prompts within the standard
+// context tier, as published in 2025, with the standard context window.
+var builtinModels = map[string]Model{
+	"claude-opus-4.1":   {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75, ContextWindow: 200_000},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75, ContextWindow: 200_000},
+	"claude-sonnet-4.5": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75, ContextWindow: 200_000},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75, ContextWindow: 200_000},
+	"claude-haiku-4.5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25, ContextWindow: 200_000},
+	"claude-3.5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1, ContextWindow: 200_000},
+	"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125, ContextWindow: 400_000},
+	"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025, ContextWindow: 400_000},
+	"gpt-4.1":           {Input: 2, Output: 8, CacheRead: 0.5, ContextWindow: 1_047_576},
+	"gpt-4.1-mini":      {Input: 0.4, Output: 1.6, CacheRead: 0.1, ContextWindow: 1_047_576},
+	"gpt-4o":            {Input: 2.5, Output: 10, CacheRead: 1.25, ContextWindow: 128_000},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.6, CacheRead: 0.075, ContextWindow: 128_000},
+	"o3":                {Input: 2, Output: 8, CacheRead: 0.5, ContextWindow: 200_000},
+	"o4-mini":           {Input: 1.1, Output: 4.4, CacheRead: 0.275, ContextWindow: 200_000},
+	"gemini-2.5-pro":    {Input: 1.25, Output: 10, CacheRead: 0.31, ContextWindow: 1_048_576},
+	"gemini-2.5-flash":  {Input: 0.3, Output: 2.5, CacheRead: 0.075, ContextWindow: 1_048_576},
+}
+
+// Builtin returns a copy of the built-in catalog.
+func Builtin() Catalog {
+	models := make(map[string]Model, len(builtinModels))
+	for name, model := range builtinModels {
+		model.Name = name
+		models[name] = model
+	}
+	return Catalog{Models: models}
+}
+
+// LoadCatalog reads a YAML or JSON catalog file.
+func LoadCatalog(path string) (Catalog, error) {
+	var catalog Catalog
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return catalog, fmt.Errorf("read price catalog: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, &catalog); err != nil {
+		return catalog, fmt.Errorf("parse price catalog %s: %w", path, err)
+	}
+	for name, model := range catalog.Models {
+		if model.Input < 0 || model.Output < 0 || model.CacheRead < 0 || model.CacheWrite < 0 {
+			return catalog, fmt.Errorf("parse price catalog %s: %s has a negative price", path, name)
+		}
+		if model.ContextWindow < 0 {
+			return catalog, fmt.Errorf("parse price catalog %s: %s has a negative context window", path, name)
+		}
+		model.Name = name
+		catalog.Models[name] = model
+	}
+	return catalog, nil
+}
+
+// Merge overlays other onto c; a model in other replaces the entry of the
+// same name.
+func (c Catalog) Merge(other Catalog) Catalog {
+	models := make(map[string]Model, len(c.Models)+len(other.Models))
+	for name, model := range c.Models {
+		models[name] = model
+	}
+	for name, model := range other.Models {
+		models[name] = model
+	}
+	return Catalog{Models: models}
+}
+
+// Lookup finds a model by case-insensitive name.
+func (c Catalog) Lookup(name string) (Model, error) {
+	name = strings.TrimSpace(name)
+	if model, ok := c.Models[name]; ok {
+		return model, nil
+	}
+	for key, model := range c.Models {
+		if strings.EqualFold(key, name) {
+			return model, nil
+		}
+	}
+	return Model{}, fmt.Errorf("unknown model %q (known: %s)", name, strings.Join(c.Names(), ", "))
+}
+
+// Names returns the catalog's model names, sorted.
+func (c Catalog) Names() []string {
+	names := make([]string, 0, len(c.Models))
+	for name := range c.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Cost is what sending a token count as input to a model costs.
+type Cost struct {
+	Model  string `json:"model"`
+	Tokens int    `json:"input_tokens"`
+	// PassUSD is one uncached pass; CachedPassUSD is one pass read from the
+	// provider's prompt cache, when the model has a cache price.
+	PassUSD       float64 `json:"pass_usd"`
+	CachedPassUSD float64 `json:"cached_pass_usd,omitempty"`
+	PassesPerDay  int     `json:"passes_per_day,omitempty"`
+	DailyUSD      float64 `json:"daily_usd,omitempty"`
+	MonthlyUSD    float64 `json:"monthly_usd,omitempty"`
+}
+
+// Cost prices tokens as uncached input, passesPerDay times a day.
+func (m Model) Cost(tokens int, passesPerDay int) Cost {
+	millions := float64(tokens) / 1_000_000
+	cost := Cost{
+		Model:         m.Name,
+		Tokens:        tokens,
+		PassUSD:       millions * m.Input,
+		CachedPassUSD: millions * m.CacheRead,
+	}
+	if passesPerDay > 0 {
+		cost.PassesPerDay = passesPerDay
+		cost.DailyUSD = cost.PassUSD * float64(passesPerDay)
+		cost.MonthlyUSD = cost.DailyUSD * daysPerMonth
+	}
+	return cost
+}
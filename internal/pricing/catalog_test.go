@@ -0,0 +1,60 @@
+package pricing
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCatalog_OverridesBuiltin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	raw := `{"models": {"claude-sonnet-4": {"input": 2.5}, "local-llm": {"input": 0.1, "cache_read": 0.01}}}`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCatalog(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	catalog := Builtin().Merge(loaded)
+
+	sonnet, err := catalog.Lookup("Claude-Sonnet-4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sonnet.Input != 2.5 || sonnet.CacheRead != 0 {
+		t.Fatalf("expected the file entry to replace the built-in one, got %+v", sonnet)
+	}
+	if local, err := catalog.Lookup("local-llm"); err != nil || local.Name != "local-llm" {
+		t.Fatalf("expected local-llm from the file, got %+v (%v)", local, err)
+	}
+	if _, err := catalog.Lookup("gpt-4o"); err != nil {
+		t.Fatalf("expected built-in models to remain, got %v", err)
+	}
+	if _, err := catalog.Lookup("nope"); err == nil {
+		t.Fatal("expected an unknown model to be rejected")
+	}
+}
+
+func TestModel_Cost(t *testing.T) {
+	model := Model{Name: "m", Input: 3, CacheRead: 0.3}
+	cost := model.Cost(2_000_000, 10)
+
+	for _, check := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"pass", cost.PassUSD, 6},
+		{"cached pass", cost.CachedPassUSD, 0.6},
+		{"daily", cost.DailyUSD, 60},
+		{"monthly", cost.MonthlyUSD, 1800},
+	} {
+		if math.Abs(check.got-check.want) > 1e-9 {
+			t.Fatalf("%s: expected $%g, got $%g", check.name, check.want, check.got)
+		}
+	}
+	if once := model.Cost(2_000_000, 0); once.DailyUSD != 0 || once.PassesPerDay != 0 {
+		t.Fatalf("expected no daily cost without passes per day, got %+v", once)
+	}
+}
@@ -0,0 +1,112 @@
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// maxBlockNames caps the names listed for a const or var block.
+const maxBlockNames = 3
+
+// parseGo lists funcs, methods, types, and const and var blocks. Each
+// spec of a grouped type declaration is its own symbol; a const or var
+// block is one symbol named after its first names.
+func parseGo(path string, src []byte) ([]span, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	newSpan := func(name string, kind string, doc *ast.CommentGroup, node ast.Node) span {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		from, to := fset.Position(start), fset.Position(node.End())
+		return span{
+			Symbol: Symbol{Name: name, Kind: kind, StartLine: from.Line, EndLine: to.Line},
+			start:  from.Offset,
+			end:    to.Offset,
+		}
+	}
+
+	var spans []span
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name, kind := d.Name.Name, KindFunc
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name, kind = receiverName(d.Recv.List[0].Type)+"."+d.Name.Name, KindMethod
+			}
+			s := newSpan(name, kind, d.Doc, d)
+			if d.Body != nil && d.Body.Rbrace > d.Body.Lbrace+1 {
+				s.body = &elision{
+					start: fset.Position(d.Body.Lbrace).Offset + 1,
+					end:   fset.Position(d.Body.Rbrace).Offset,
+					stub:  braceStub,
+				}
+			}
+			spans = append(spans, s)
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.TYPE:
+				if !d.Lparen.IsValid() && len(d.Specs) == 1 {
+					spans = append(spans, newSpan(d.Specs[0].(*ast.TypeSpec).Name.Name, KindType, d.Doc, d))
+					continue
+				}
+				for _, spec := range d.Specs {
+					ts := spec.(*ast.TypeSpec)
+					spans = append(spans, newSpan(ts.Name.Name, KindType, ts.Doc, ts))
+				}
+			case token.CONST, token.VAR:
+				kind := KindConst
+				if d.Tok == token.VAR {
+					kind = KindVar
+				}
+				spans = append(spans, newSpan(blockName(d), kind, d.Doc, d))
+			}
+		}
+	}
+	return spans, nil
+}
+
+// receiverName renders a method receiver as T or (*T), without type
+// parameters.
+func receiverName(expr ast.Expr) string {
+	pointer := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		pointer = true
+		expr = star.X
+	}
+	switch x := expr.(type) {
+	case *ast.IndexExpr:
+		expr = x.X
+	case *ast.IndexListExpr:
+		expr = x.X
+	}
+	name := "?"
+	if ident, ok := expr.(*ast.Ident); ok {
+		name = ident.Name
+	}
+	if pointer {
+		return "(*" + name + ")"
+	}
+	return name
+}
+
+// blockName lists the first names a const or var declaration declares.
+func blockName(d *ast.GenDecl) string {
+	var names []string
+	for _, spec := range d.Specs {
+		for _, ident := range spec.(*ast.ValueSpec).Names {
+			names = append(names, ident.Name)
+		}
+	}
+	if len(names) > maxBlockNames {
+		names = append(names[:maxBlockNames], "...")
+	}
+	return strings.Join(names, ", ")
+}
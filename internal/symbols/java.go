@@ -0,0 +1,51 @@
+package symbols
+
+import "regexp"
+
+var (
+	javaAnnotations = `^\s*(?:@[\w.]+(?:\([^)]*\))?\s+)*`
+	javaType        = regexp.MustCompile(javaAnnotations + `(?:(?:public|protected|private|static|final|abstract|sealed|non-sealed|strictfp)\s+)*(class|interface|enum|record|@interface)\s+(\w+)`)
+	javaMethod      = regexp.MustCompile(javaAnnotations + `(?:(?:public|protected|private|static|final|abstract|synchronized|native|default|strictfp)\s+)*(?:<[^>]*>\s+)?(?:[\w.$]+(?:<[^()]*>)?(?:\[\])*\s+)?(\w+)\s*\(`)
+)
+
+// javaKeywords look like method headers in a type body but are not.
+var javaKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "synchronized": true,
+	"return": true, "new": true, "throw": true, "else": true, "try": true, "do": true,
+}
+
+var javaTypeKinds = map[string]string{
+	"class":      KindClass,
+	"record":     KindClass,
+	"interface":  KindInterface,
+	"@interface": KindInterface,
+	"enum":       KindEnum,
+}
+
+// java outlines classes, interfaces, enums and records with their methods
+// and constructors, nested types included.
+var java = braceLang{
+	sep: ".",
+	match: func(line string, in scope, body bool, first bool) (decl, bool) {
+		if m := javaType.FindStringSubmatch(line); m != nil {
+			return decl{name: m[2], kind: javaTypeKinds[m[1]], descend: true}, true
+		}
+		if in.kind == "" {
+			return decl{}, false
+		}
+		// An enum body opens with its constants, which look like calls.
+		if in.kind == KindEnum && first {
+			return decl{}, false
+		}
+		if m := javaMethod.FindStringSubmatch(line); m != nil && !javaKeywords[m[1]] {
+			return decl{name: m[1], kind: KindMethod}, true
+		}
+		return decl{}, false
+	},
+}
+
+func parseJava(path string, src []byte) ([]span, error) {
+	o := newOutline("Java", src)
+	o.braces(java, 0, len(src), scope{})
+	return o.spans, nil
+}
@@ -0,0 +1,285 @@
+package symbols
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/Napageneral/tokcount/internal/lexical"
+)
+
+// outline is a file being outlined. masked is src with comments and
+// strings blanked, so brackets and keywords in it are real code; comments
+// is src with everything but comments blanked.
+type outline struct {
+	src        []byte
+	masked     []byte
+	comments   []byte
+	lineStarts []int
+	spans      []span
+}
+
+func newOutline(lang string, src []byte) *outline {
+	o := &outline{
+		src:        src,
+		masked:     lexical.Mask(lang, src),
+		comments:   lexical.Comments(lang, src),
+		lineStarts: []int{0},
+	}
+	for i, c := range src {
+		if c == '\n' {
+			o.lineStarts = append(o.lineStarts, i+1)
+		}
+	}
+	return o
+}
+
+// lineOf returns the 0-based line holding offset.
+func (o *outline) lineOf(offset int) int {
+	return sort.Search(len(o.lineStarts), func(i int) bool { return o.lineStarts[i] > offset }) - 1
+}
+
+// lineEnd is the offset of the newline ending line, or the end of the file.
+func (o *outline) lineEnd(line int) int {
+	if line+1 < len(o.lineStarts) {
+		return o.lineStarts[line+1] - 1
+	}
+	return len(o.src)
+}
+
+func (o *outline) maskedLine(line int) []byte {
+	return o.masked[o.lineStarts[line]:o.lineEnd(line)]
+}
+
+// isCode reports whether line has code once comments and strings are
+// masked.
+func (o *outline) isCode(line int) bool {
+	return len(bytes.TrimSpace(o.maskedLine(line))) > 0
+}
+
+// docStart walks up from a declaration's first line over the comment
+// lines, annotations and attributes directly above it. Lines holding only
+// a string, such as a Python docstring, end the walk: they belong to the
+// code above.
+func (o *outline) docStart(line int) int {
+	for line > 0 {
+		prev := line - 1
+		text := bytes.TrimSpace(o.maskedLine(prev))
+		comment := bytes.TrimSpace(o.comments[o.lineStarts[prev]:o.lineEnd(prev)])
+		switch {
+		case len(comment) > 0 && len(text) == 0:
+		case bytes.HasPrefix(text, []byte("@")), bytes.HasPrefix(text, []byte("#[")):
+		default:
+			return line
+		}
+		line = prev
+	}
+	return line
+}
+
+// lastCodeEnd is the end of the last line with code in [from, to), or the
+// end of from when none has.
+func (o *outline) lastCodeEnd(from int, to int) int {
+	for line := to - 1; line > from; line-- {
+		if o.isCode(line) {
+			return o.lineEnd(line)
+		}
+	}
+	return o.lineEnd(from)
+}
+
+// add records a declaration starting on line and ending at offset end.
+func (o *outline) add(name string, kind string, in scope, line int, end int) {
+	start := o.docStart(line)
+	if in.prefix != "" {
+		name = in.prefix + name
+	}
+	o.spans = append(o.spans, span{
+		Symbol: Symbol{
+			Name:      name,
+			Kind:      kind,
+			Parent:    in.parent,
+			StartLine: start + 1,
+			EndLine:   o.lineOf(end-1) + 1,
+		},
+		start: o.lineStarts[start],
+		end:   end,
+	})
+}
+
+// elide marks src[start:end] as the body of the last declaration added.
+func (o *outline) elide(start int, end int, stub string) {
+	if start < end {
+		o.spans[len(o.spans)-1].body = &elision{start: start, end: end, stub: stub}
+	}
+}
+
+// scope is the declaration a block belongs to.
+type scope struct {
+	// parent is the Name of the enclosing symbol; prefix qualifies the
+	// names declared inside it.
+	parent string
+	prefix string
+	kind   string
+}
+
+// decl is a declaration recognized on a header line.
+type decl struct {
+	name string
+	kind string
+	// descend outlines the declarations in its body.
+	descend bool
+	// prefix, when set, replaces the name children are qualified with
+	// (a Rust impl qualifies methods with its type).
+	prefix string
+}
+
+// braceLang tells the brace parser what declares what.
+type braceLang struct {
+	// sep joins a container's name to the names declared inside it.
+	sep string
+	// match recognizes a declaration on one line of a statement header,
+	// in scope in. body reports whether the statement has a { } body, and
+	// first whether it is the first statement of its block.
+	match func(line string, in scope, body bool, first bool) (decl, bool)
+}
+
+// braces outlines the statements of masked[start:end]. A statement runs
+// to the first ";" or "{" outside brackets; a "{" takes the statement to
+// its matching "}". A header spanning lines (annotations, or statements
+// without semicolons) is matched line by line: the last declaration owns
+// the body, and earlier ones end at their last line of code.
+func (o *outline) braces(lang braceLang, start int, end int, in scope) {
+	m := o.masked
+	first := true
+	for pos := start; pos < end; {
+		for pos < end && isSpace(m[pos]) {
+			pos++
+		}
+		if pos >= end {
+			return
+		}
+		stmt, term, depth := pos, -1, 0
+	scan:
+		for j := pos; j < end; j++ {
+			switch m[j] {
+			case '(', '[':
+				depth++
+			case ')', ']':
+				if depth > 0 {
+					depth--
+				}
+			case '{':
+				if depth == 0 {
+					term = j
+					break scan
+				}
+				depth++
+			case '}':
+				if depth > 0 {
+					depth--
+				}
+			case ';':
+				if depth == 0 {
+					term = j
+					break scan
+				}
+			}
+		}
+
+		headerEnd, next := end, end
+		body, bodyEnd := false, end
+		if term >= 0 {
+			headerEnd, next = term, term+1
+			if m[term] == '{' {
+				body = true
+				bodyEnd = o.matchBrace(term, end)
+				next = bodyEnd + 1
+				if next > end {
+					next = end
+				}
+				// "const f = () => { ... };" ends at the semicolon.
+				if k := skipBlanks(m, next, end); k < end && m[k] == ';' {
+					next = k + 1
+				}
+			}
+		}
+		o.header(lang, stmt, headerEnd, next, term, body, bodyEnd, in, first)
+		first = false
+		pos = next
+	}
+}
+
+func (o *outline) header(lang braceLang, stmt int, headerEnd int, stmtEnd int, term int, body bool, bodyEnd int, in scope, first bool) {
+	type found struct {
+		decl
+		line int
+	}
+	var matches []found
+	firstLine, lastLine := o.lineOf(stmt), o.lineOf(headerEnd)
+	for line := firstLine; line <= lastLine; line++ {
+		from, to := o.lineStarts[line], o.lineEnd(line)
+		if from < stmt {
+			from = stmt
+		}
+		if to > headerEnd {
+			to = headerEnd
+		}
+		if from >= to {
+			continue
+		}
+		if d, ok := lang.match(string(o.masked[from:to]), in, body, first); ok {
+			matches = append(matches, found{decl: d, line: line})
+		}
+	}
+
+	for i, match := range matches {
+		if i < len(matches)-1 {
+			o.add(match.name, match.kind, in, match.line, o.lastCodeEnd(match.line, o.docStart(matches[i+1].line)))
+			continue
+		}
+		end := stmtEnd
+		if term < 0 {
+			end = o.lastCodeEnd(match.line, lastLine+1)
+		}
+		o.add(match.name, match.kind, in, match.line, end)
+		if body && bodyEnd < len(o.src) && o.masked[bodyEnd] == '}' && (match.kind == KindFunc || match.kind == KindMethod) {
+			o.elide(term+1, bodyEnd, braceStub)
+		}
+		if body && match.descend {
+			name := in.prefix + match.name
+			prefix := name
+			if match.prefix != "" {
+				prefix = in.prefix + match.prefix
+			}
+			o.braces(lang, term+1, bodyEnd, scope{parent: name, prefix: prefix + lang.sep, kind: match.kind})
+		}
+	}
+}
+
+// matchBrace returns the offset of the "}" closing the "{" at open, or end.
+func (o *outline) matchBrace(open int, end int) int {
+	depth := 0
+	for j := open; j < end; j++ {
+		switch o.masked[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return end
+}
+
+func skipBlanks(m []byte, pos int, end int) int {
+	for pos < end && (m[pos] == ' ' || m[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == '\v'
+}
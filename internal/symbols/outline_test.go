@@ -0,0 +1,279 @@
+package symbols
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+const tsSource = `import { x } from "y"
+
+/** Adds. */
+export function add(a: number, b: number): number {
+  return a + b
+}
+
+export const mul = (a: number, b: number) => a * b
+export const handler = async (
+  event: Event,
+) => {
+  const inner = () => 1
+  return inner()
+};
+
+@Component({ selector: "x" })
+export class Widget extends Base {
+  private count = 0;
+  constructor(private svc: Service) {
+    super();
+  }
+  get value(): number { return this.count }
+  onClick = (e: MouseEvent) => {
+    this.count++;
+  };
+  abstract render(): void;
+}
+
+interface Props {
+  a: string;
+}
+type Id = string | number;
+export enum Color { Red, Green }
+namespace NS {
+  export function inside() {}
+}
+`
+
+const javaSource = `package x;
+
+import java.util.List;
+
+/** A widget. */
+@Deprecated
+public class Widget<T> implements Runnable {
+    private final List<String> names = new ArrayList<>();
+
+    public Widget(int n) {
+        this.n = n;
+    }
+
+    @Override
+    public void run() {
+        if (x) { y(); }
+    }
+
+    public static <R> List<R> map(Function<T, R> f) { return null; }
+
+    interface Listener {
+        void onEvent(String e);
+    }
+
+    enum Mode {
+        FAST("f"), SLOW("s");
+        Mode(String s) {}
+        String label() { return ""; }
+    }
+}
+`
+
+const rustSource = `//! Crate doc.
+use std::fmt;
+
+/// A point.
+#[derive(Debug, Clone)]
+pub struct Point { x: i32, y: i32 }
+
+pub struct Unit;
+
+impl<T> fmt::Display for Wrapper<T>
+where
+    T: fmt::Debug,
+{
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{:?}", self.0)
+    }
+}
+
+impl Point {
+    pub const ORIGIN: Point = Point { x: 0, y: 0 };
+    pub fn new(x: i32, y: i32) -> Self { Self { x, y } }
+    fn len<'a>(&'a self) -> char { '{' }
+}
+
+pub trait Shape {
+    fn area(&self) -> f64;
+    fn name(&self) -> String { "shape".into() }
+}
+
+mod inner {
+    pub(crate) async fn go() {}
+}
+mod other;
+
+static COUNT: u32 = 0;
+macro_rules! square { ($x:expr) => { $x * $x }; }
+pub type Res<T> = Result<T, Error>;
+`
+
+const pythonSource = `"""Module doc."""
+import os
+
+
+# Helper comment.
+def helper(a,
+           b):
+    """Doc."""
+    def inner():
+        return 1
+    return inner()
+
+
+@dataclass
+class Shape:
+    """A shape."""
+
+    name: str = "x"
+
+    @property
+    def area(self):
+        return 0
+    # trailing comment in body
+
+    class Meta:
+        def m(self): pass
+
+async def main(
+    argv,
+):
+    x = {
+"key": 1,
+    }
+    return x
+`
+
+func TestRun_Outlines(t *testing.T) {
+	tests := []struct {
+		language string
+		source   string
+		want     []string
+	}{
+		{"TypeScript", tsSource, []string{
+			"func add - 3-6",
+			"func mul - 8-8",
+			"func handler - 9-14",
+			"class Widget - 16-27",
+			"method Widget.constructor Widget 19-21",
+			"method Widget.value Widget 22-22",
+			"method Widget.onClick Widget 23-25",
+			"method Widget.render Widget 26-26",
+			"interface Props - 29-31",
+			"type Id - 32-32",
+			"enum Color - 33-33",
+			"module NS - 34-36",
+			"func NS.inside NS 35-35",
+		}},
+		{"Java", javaSource, []string{
+			"class Widget - 5-30",
+			"method Widget.Widget Widget 10-12",
+			"method Widget.run Widget 14-17",
+			"method Widget.map Widget 19-19",
+			"interface Widget.Listener Widget 21-23",
+			"method Widget.Listener.onEvent Widget.Listener 22-22",
+			"enum Widget.Mode Widget 25-29",
+			"method Widget.Mode.Mode Widget.Mode 27-27",
+			"method Widget.Mode.label Widget.Mode 28-28",
+		}},
+		{"Rust", rustSource, []string{
+			"struct Point - 4-6",
+			"struct Unit - 8-8",
+			"impl fmt::Display for Wrapper - 10-17",
+			"method Wrapper::fmt fmt::Display for Wrapper 14-16",
+			"impl Point - 19-23",
+			"const Point::ORIGIN Point 20-20",
+			"method Point::new Point 21-21",
+			"method Point::len Point 22-22",
+			"trait Shape - 25-28",
+			"method Shape::area Shape 26-26",
+			"method Shape::name Shape 27-27",
+			"module inner - 30-32",
+			"func inner::go inner 31-31",
+			"var COUNT - 35-35",
+			"macro square - 36-36",
+			"type Res - 37-37",
+		}},
+		{"Python", pythonSource, []string{
+			"func helper - 5-11",
+			"class Shape - 14-26",
+			"method Shape.area Shape 20-22",
+			"class Shape.Meta Shape 25-26",
+			"method Shape.Meta.m Shape.Meta 26-26",
+			"func main - 28-34",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			result, err := Run(Options{
+				Files:     []count.FileStat{{Path: "src", Language: tt.language}},
+				Read:      func(string) ([]byte, error) { return []byte(tt.source), nil },
+				Tokenizer: byteTokenizer{},
+				Sort:      SortFile,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, sym := range result.Symbols {
+				parent := sym.Parent
+				if parent == "" {
+					parent = "-"
+				}
+				got = append(got, fmt.Sprintf("%s %s %s %d-%d", sym.Kind, sym.Name, parent, sym.StartLine, sym.EndLine))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected symbols\n%v\ngot\n%v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRun_NestedSymbolsCountOnce(t *testing.T) {
+	result, err := Run(Options{
+		Files:     []count.FileStat{{Path: "a.py", Language: "Python"}},
+		Read:      func(string) ([]byte, error) { return []byte("class A:\n    def f(self):\n        pass\n"), nil },
+		Tokenizer: byteTokenizer{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Symbols) != 2 || result.Tokens != len("class A:\n    def f(self):\n        pass") {
+		t.Fatalf("expected the class alone to make up the total, got %d tokens over %+v", result.Tokens, result.Symbols)
+	}
+}
+
+func TestRun_DocstringsStayWithTheirDefinition(t *testing.T) {
+	a := "def a():\n    \"\"\"doc\"\"\""
+	b := "def b():\n    pass"
+	class := "class A:\n    \"\"\"Class doc.\"\"\"\n    def f(self):\n        pass"
+	result, err := Run(Options{
+		Files:     []count.FileStat{{Path: "x.py", Language: "Python"}},
+		Read:      func(string) ([]byte, error) { return []byte(a + "\n" + b + "\n\n" + class + "\n"), nil },
+		Tokenizer: byteTokenizer{},
+		Sort:      SortFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, sym := range result.Symbols {
+		got = append(got, fmt.Sprintf("%s %d-%d", sym.Name, sym.StartLine, sym.EndLine))
+	}
+	want := []string{"a 1-2", "b 3-4", "A 6-9", "A.f 8-9"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected symbols %v, got %v", want, got)
+	}
+	if wantTokens := len(a) + len(b) + len(class); result.Tokens != wantTokens {
+		t.Fatalf("expected %d tokens with no line counted twice, got %d", wantTokens, result.Tokens)
+	}
+}
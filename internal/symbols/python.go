@@ -0,0 +1,114 @@
+package symbols
+
+import (
+	"bytes"
+	"regexp"
+)
+
+var (
+	pyDef   = regexp.MustCompile(`^(?:async\s+)?def\s+(\w+)`)
+	pyClass = regexp.MustCompile(`^class\s+(\w+)`)
+)
+
+// pyFrame is an open def or class. Defs nested in a def are tracked, so
+// their bodies do not close the outer block, but not reported.
+type pyFrame struct {
+	indent int
+	line   int
+	name   string
+	kind   string
+	in     scope
+	hidden bool
+	// body is the first statement of the block, after any docstring; 0
+	// until one is seen.
+	body int
+}
+
+// parsePython outlines classes, functions and methods, nested classes
+// included, from indentation. Only lines that start a statement count:
+// lines inside brackets or after a backslash continue the one above.
+func parsePython(path string, src []byte) ([]span, error) {
+	o := newOutline("Python", src)
+	var stack []pyFrame
+	closeFrames := func(indent int, line int) {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !f.hidden {
+				last := o.pythonBlockEnd(f, line)
+				o.add(f.name, f.kind, f.in, f.line, o.lineEnd(last))
+				if f.kind != KindClass && f.body > 0 && f.body <= last {
+					from := o.lineStarts[f.body]
+					indent := len(o.src[from:o.lineEnd(f.body)]) - len(bytes.TrimLeft(o.src[from:o.lineEnd(f.body)], " \t"))
+					o.elide(from, o.lineEnd(last), string(o.src[from:from+indent])+"...")
+				}
+			}
+		}
+	}
+
+	depth, continued := 0, false
+	for line := range o.lineStarts {
+		text := o.maskedLine(line)
+		logical := depth == 0 && !continued
+		for _, c := range text {
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+		continued = bytes.HasSuffix(bytes.TrimRight(text, " \t\r"), []byte(`\`))
+
+		trimmed := bytes.TrimLeft(text, " \t")
+		if !logical || len(bytes.TrimSpace(trimmed)) == 0 {
+			continue
+		}
+		indent := len(text) - len(trimmed)
+		closeFrames(indent, line)
+		if len(stack) > 0 && stack[len(stack)-1].body == 0 {
+			stack[len(stack)-1].body = line
+		}
+
+		kind, name := "", ""
+		if m := pyDef.FindSubmatch(trimmed); m != nil {
+			kind, name = KindFunc, string(m[1])
+		} else if m := pyClass.FindSubmatch(trimmed); m != nil {
+			kind, name = KindClass, string(m[1])
+		}
+		if kind == "" {
+			continue
+		}
+		frame := pyFrame{indent: indent, line: line, name: name, kind: kind}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			frame.hidden = parent.hidden || parent.kind != KindClass
+			qualified := parent.in.prefix + parent.name
+			frame.in = scope{parent: qualified, prefix: qualified + ".", kind: KindClass}
+			if kind == KindFunc {
+				frame.kind = KindMethod
+			}
+		}
+		stack = append(stack, frame)
+	}
+	closeFrames(0, len(o.lineStarts))
+	return o.spans, nil
+}
+
+// pythonBlockEnd is the last line of f's block before line: the last with
+// code, or with a comment or docstring indented into the block.
+func (o *outline) pythonBlockEnd(f pyFrame, line int) int {
+	for last := line - 1; last > f.line; last-- {
+		if o.isCode(last) {
+			return last
+		}
+		original := o.src[o.lineStarts[last]:o.lineEnd(last)]
+		trimmed := bytes.TrimLeft(original, " \t")
+		if len(bytes.TrimSpace(trimmed)) > 0 && len(original)-len(trimmed) > f.indent {
+			return last
+		}
+	}
+	return f.line
+}
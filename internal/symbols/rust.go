@@ -0,0 +1,109 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	rustPrefix = `^\s*(?:#\[[^\]]*\]\s*)*(?:pub(?:\([^)]*\))?\s+)?`
+	rustFn     = regexp.MustCompile(rustPrefix + `(?:default\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+)?fn\s+(\w+)`)
+	rustItem   = regexp.MustCompile(rustPrefix + `(?:unsafe\s+)?(?:auto\s+)?(struct|enum|union|trait|type|mod)\s+(\w+)`)
+	rustValue  = regexp.MustCompile(rustPrefix + `(const|static)\s+(?:mut\s+)?(\w+)\s*:`)
+	rustMacro  = regexp.MustCompile(`^\s*(?:#\[[^\]]*\]\s*)*macro_rules!\s*(\w+)`)
+	rustImpl   = regexp.MustCompile(`^\s*(?:#\[[^\]]*\]\s*)*(?:unsafe\s+)?impl\b(.*)$`)
+)
+
+var rustItemKinds = map[string]string{
+	"struct": KindStruct,
+	"union":  KindStruct,
+	"enum":   KindEnum,
+	"trait":  KindTrait,
+	"type":   KindType,
+	"mod":    KindModule,
+}
+
+// rust outlines functions, structs, enums, traits, impl blocks and their
+// methods, inline modules, type aliases, constants, statics and
+// macro_rules.
+var rust = braceLang{
+	sep: "::",
+	match: func(line string, in scope, body bool, first bool) (decl, bool) {
+		if m := rustFn.FindStringSubmatch(line); m != nil {
+			kind := KindFunc
+			if in.kind == KindImpl || in.kind == KindTrait {
+				kind = KindMethod
+			}
+			return decl{name: m[1], kind: kind}, true
+		}
+		if m := rustImpl.FindStringSubmatch(line); m != nil && body {
+			name, self := implName(m[1])
+			return decl{name: name, kind: KindImpl, descend: true, prefix: self}, true
+		}
+		if m := rustItem.FindStringSubmatch(line); m != nil {
+			kind := rustItemKinds[m[1]]
+			// "mod name;" only points at another file.
+			if kind == KindModule && !body {
+				return decl{}, false
+			}
+			return decl{name: m[2], kind: kind, descend: kind == KindTrait || kind == KindModule}, true
+		}
+		if m := rustValue.FindStringSubmatch(line); m != nil {
+			kind := KindConst
+			if m[1] == "static" {
+				kind = KindVar
+			}
+			return decl{name: m[2], kind: kind}, true
+		}
+		if m := rustMacro.FindStringSubmatch(line); m != nil {
+			return decl{name: m[1], kind: KindMacro}, true
+		}
+		return decl{}, false
+	},
+}
+
+// implName names an impl header ("<T> Display for Wrapper<T> where ...")
+// as "Display for Wrapper" and returns the type its methods belong to.
+func implName(header string) (string, string) {
+	header = strings.TrimSpace(stripGenerics(header))
+	if i := strings.Index(header, " where "); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.Join(strings.Fields(strings.TrimSuffix(header, "where")), " ")
+	self := header
+	if i := strings.LastIndex(header, " for "); i >= 0 {
+		self = header[i+len(" for "):]
+	}
+	// "&'a mut dyn Trait" belongs to Trait.
+	var parts []string
+	for _, field := range strings.Fields(strings.TrimLeft(self, "&*")) {
+		if strings.HasPrefix(field, "'") || field == "mut" || field == "dyn" || field == "const" {
+			continue
+		}
+		parts = append(parts, field)
+	}
+	return header, strings.Join(parts, " ")
+}
+
+// stripGenerics drops <...> groups, nested ones included.
+func stripGenerics(s string) string {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '<':
+			depth++
+		case c == '>' && depth > 0 && (i == 0 || s[i-1] != '-'):
+			depth--
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func parseRust(path string, src []byte) ([]span, error) {
+	o := newOutline("Rust", src)
+	o.braces(rust, 0, len(src), scope{})
+	return o.spans, nil
+}
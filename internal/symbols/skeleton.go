@@ -0,0 +1,44 @@
+package symbols
+
+import "sort"
+
+// braceStub replaces the inside of an elided { } body.
+const braceStub = " ... "
+
+// Skeleton returns src with the bodies of its functions and methods
+// elided, keeping signatures, type declarations and doc comments. ok is
+// false when lang has no parser, src does not parse, or there was no body
+// to elide.
+func Skeleton(lang string, src []byte) ([]byte, bool) {
+	parse, ok := parsers[lang]
+	if !ok {
+		return nil, false
+	}
+	spans, err := parse("", src)
+	if err != nil {
+		return nil, false
+	}
+	var bodies []elision
+	for _, s := range spans {
+		if s.body != nil {
+			bodies = append(bodies, *s.body)
+		}
+	}
+	if len(bodies) == 0 {
+		return nil, false
+	}
+	sort.Slice(bodies, func(i, j int) bool { return bodies[i].start < bodies[j].start })
+
+	out := make([]byte, 0, len(src))
+	pos := 0
+	for _, body := range bodies {
+		// A body inside one already elided goes with it.
+		if body.start < pos {
+			continue
+		}
+		out = append(out, src[pos:body.start]...)
+		out = append(out, body.stub...)
+		pos = body.end
+	}
+	return append(out, src[pos:]...), true
+}
@@ -0,0 +1,50 @@
+package symbols
+
+import "testing"
+
+func TestSkeleton_ElidesBodies(t *testing.T) {
+	tests := []struct {
+		language string
+		source   string
+		want     string
+	}{
+		{
+			"Go",
+			"package p\n\n// Add adds.\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\ntype T struct{ N int }\n\nfunc (T) Empty() {}\n",
+			"package p\n\n// Add adds.\nfunc Add(a, b int) int { ... }\n\ntype T struct{ N int }\n\nfunc (T) Empty() {}\n",
+		},
+		{
+			"TypeScript",
+			"export class A {\n  run(s: string): void {\n    const o = { s };\n  }\n}\nconst f = () => 1\n",
+			"export class A {\n  run(s: string): void { ... }\n}\nconst f = () => 1\n",
+		},
+		{
+			"Python",
+			"class A:\n    def f(self):\n        \"\"\"Doc.\"\"\"\n        x = 1\n        return x\n\n    def g(self): pass\n",
+			"class A:\n    def f(self):\n        \"\"\"Doc.\"\"\"\n        ...\n\n    def g(self): pass\n",
+		},
+		{
+			"Rust",
+			"trait T {\n    fn a(&self);\n    fn b(&self) -> &str { \"}\" }\n}\n",
+			"trait T {\n    fn a(&self);\n    fn b(&self) -> &str { ... }\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			got, ok := Skeleton(tt.language, []byte(tt.source))
+			if !ok || string(got) != tt.want {
+				t.Fatalf("expected skeleton\n%s\ngot (ok=%v)\n%s", tt.want, ok, got)
+			}
+		})
+	}
+
+	for _, source := range []struct{ language, text string }{
+		{"Markdown", "# title\n"},
+		{"Go", "package p\n\nfunc {"},
+		{"Java", "interface I {\n    void run();\n}\n"},
+	} {
+		if _, ok := Skeleton(source.language, []byte(source.text)); ok {
+			t.Fatalf("expected no skeleton for %s %q", source.language, source.text)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package symbols counts tokens per declaration of source files. Go is
+// parsed with go/ast; TypeScript, JavaScript, Python, Java and Rust get a
+// lightweight outline parser that reads declarations off bracket or
+// indentation structure, with comments and strings masked out.
+package symbols
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Napageneral/tokcount/internal/count"
+	"github.com/Napageneral/tokcount/internal/tokenizer"
+)
+
+// Symbol kinds.
+const (
+	KindFunc      = "func"
+	KindMethod    = "method"
+	KindType      = "type"
+	KindConst     = "const"
+	KindVar       = "var"
+	KindClass     = "class"
+	KindInterface = "interface"
+	KindEnum      = "enum"
+	KindStruct    = "struct"
+	KindTrait     = "trait"
+	KindImpl      = "impl"
+	KindModule    = "module"
+	KindMacro     = "macro"
+)
+
+// Sort orders.
+const (
+	SortTokens = "tokens"
+	SortName   = "name"
+	SortFile   = "file"
+	SortLines  = "lines"
+)
+
+// Symbol is one declaration. Its span starts at the doc comment, when
+// there is one, and Tokens counts the whole span.
+type Symbol struct {
+	// Name is qualified by the enclosing symbols, as in Class.method.
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Parent is the Name of the enclosing symbol, for methods and nested
+	// types; its span contains this one.
+	Parent string `json:"parent,omitempty"`
+	File   string `json:"file"`
+	// StartLine and EndLine are 1-based and inclusive.
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+	Tokens    int `json:"tokens"`
+}
+
+// Lines is the length of the symbol's span.
+func (s Symbol) Lines() int {
+	return s.EndLine - s.StartLine + 1
+}
+
+// Location renders the span as file:start-end.
+func (s Symbol) Location() string {
+	if s.StartLine == s.EndLine {
+		return fmt.Sprintf("%s:%d", s.File, s.StartLine)
+	}
+	return fmt.Sprintf("%s:%d-%d", s.File, s.StartLine, s.EndLine)
+}
+
+// Options controls a symbol scan.
+type Options struct {
+	// Files are the candidates, typically count.Result.Files of a scan;
+	// files in languages without a parser are skipped.
+	Files []count.FileStat
+	// Read returns the content of a candidate by its slash path.
+	Read      func(path string) ([]byte, error)
+	Tokenizer tokenizer.Tokenizer
+	// Sort is SortTokens (default, largest first), SortName, SortFile or
+	// SortLines (longest first).
+	Sort string
+}
+
+// Result lists the symbols of every parsed file.
+type Result struct {
+	Tokenizer string `json:"tokenizer"`
+	Files     int    `json:"files"`
+	// Tokens sums the symbols without a parent, so nested ones are not
+	// counted twice.
+	Tokens  int      `json:"tokens"`
+	Symbols []Symbol `json:"symbols"`
+	// Unparsed lists files that failed to parse; they have no symbols.
+	Unparsed []string `json:"unparsed,omitempty"`
+}
+
+// parseFunc extracts the symbols of one file, without token counts.
+type parseFunc func(path string, src []byte) ([]span, error)
+
+// span is a symbol with its byte range in the file. A function or method
+// with a body also records the range Skeleton replaces with stub.
+type span struct {
+	Symbol
+	start, end int
+	body       *elision
+}
+
+// elision replaces src[start:end] in a skeleton.
+type elision struct {
+	start, end int
+	stub       string
+}
+
+// parsers maps language.Detect names to their parser.
+var parsers = map[string]parseFunc{
+	"Go":         parseGo,
+	"TypeScript": parseTypeScript,
+	"TSX":        parseTypeScript,
+	"JavaScript": parseTypeScript,
+	"Java":       parseJava,
+	"Rust":       parseRust,
+	"Python":     parsePython,
+}
+
+// Run parses every candidate with a parser for its language and counts the
+// tokens of each symbol.
+func Run(opts Options) (*Result, error) {
+	if opts.Tokenizer == nil || opts.Read == nil {
+		return nil, fmt.Errorf("symbols: tokenizer and reader are required")
+	}
+	less, err := sorter(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Tokenizer: opts.Tokenizer.Description()}
+	for _, file := range opts.Files {
+		parse, ok := parsers[file.Language]
+		if !ok {
+			continue
+		}
+		src, err := opts.Read(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file.Path, err)
+		}
+		spans, err := parse(file.Path, src)
+		if err != nil {
+			result.Unparsed = append(result.Unparsed, file.Path)
+			continue
+		}
+		result.Files++
+		for _, s := range spans {
+			s.File = file.Path
+			s.Tokens = tokenizer.CountFile(opts.Tokenizer, file.Path, string(src[s.start:s.end]))
+			if s.Parent == "" {
+				result.Tokens += s.Tokens
+			}
+			result.Symbols = append(result.Symbols, s.Symbol)
+		}
+	}
+	sort.SliceStable(result.Symbols, func(i, j int) bool { return less(result.Symbols[i], result.Symbols[j]) })
+	return result, nil
+}
+
+func sorter(order string) (func(a, b Symbol) bool, error) {
+	byFile := func(a, b Symbol) bool {
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.StartLine < b.StartLine
+	}
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "", SortTokens:
+		return func(a, b Symbol) bool {
+			if a.Tokens != b.Tokens {
+				return a.Tokens > b.Tokens
+			}
+			return byFile(a, b)
+		}, nil
+	case SortName:
+		return func(a, b Symbol) bool {
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return byFile(a, b)
+		}, nil
+	case SortFile:
+		return byFile, nil
+	case SortLines:
+		return func(a, b Symbol) bool {
+			if a.Lines() != b.Lines() {
+				return a.Lines() > b.Lines()
+			}
+			return byFile(a, b)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported symbol sort: %s (use: tokens, name, file or lines)", order)
+	}
+}
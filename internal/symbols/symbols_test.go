@@ -0,0 +1,123 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Napageneral/tokcount/internal/count"
+)
+
+// byteTokenizer counts one token per byte.
+type byteTokenizer struct{}
+
+func (byteTokenizer) Count(text string) int { return len(text) }
+func (byteTokenizer) Name() string          { return "bytes" }
+func (byteTokenizer) Description() string   { return "bytes (test)" }
+
+const goSource = `package shapes
+
+import "math"
+
+// Pi is rounded.
+const (
+	Pi = 3.14
+	E  = 2.72
+	Phi, Sqrt2 = 1.62, 1.41
+)
+
+// Circle is round.
+type Circle struct {
+	R float64
+}
+
+type (
+	// Side is a length.
+	Side  float64
+	Angle float64
+)
+
+// Area returns the area.
+func (c *Circle) Area() float64 {
+	return math.Pi * c.R * c.R
+}
+
+func (s Side) Double() Side { return s * 2 }
+
+func New(r float64) *Circle { return &Circle{R: r} }
+
+var registry = map[string]Circle{}
+`
+
+func TestRun_GoSymbols(t *testing.T) {
+	files := map[string]string{
+		"shapes/shapes.go": goSource,
+		"shapes/broken.go": "package shapes\nfunc {",
+		"README.md":        "# shapes\n",
+	}
+	result, err := Run(Options{
+		Files: []count.FileStat{
+			{Path: "README.md", Language: "Markdown"},
+			{Path: "shapes/broken.go", Language: "Go"},
+			{Path: "shapes/shapes.go", Language: "Go"},
+		},
+		Read:      func(path string) ([]byte, error) { return []byte(files[path]), nil },
+		Tokenizer: byteTokenizer{},
+		Sort:      SortFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Files != 1 || !reflect.DeepEqual(result.Unparsed, []string{"shapes/broken.go"}) {
+		t.Fatalf("expected 1 parsed and 1 unparsed file, got %d and %v", result.Files, result.Unparsed)
+	}
+	type row struct {
+		name, kind string
+		start, end int
+	}
+	var got []row
+	for _, sym := range result.Symbols {
+		got = append(got, row{sym.Name, sym.Kind, sym.StartLine, sym.EndLine})
+	}
+	want := []row{
+		{"Pi, E, Phi, ...", KindConst, 5, 10},
+		{"Circle", KindType, 12, 15},
+		{"Side", KindType, 18, 19},
+		{"Angle", KindType, 20, 20},
+		{"(*Circle).Area", KindMethod, 23, 26},
+		{"Side.Double", KindMethod, 28, 28},
+		{"New", KindFunc, 30, 30},
+		{"registry", KindVar, 32, 32},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected symbols\n%v\ngot\n%v", want, got)
+	}
+
+	newFunc := result.Symbols[6]
+	if wantTokens := len("func New(r float64) *Circle { return &Circle{R: r} }"); newFunc.Tokens != wantTokens {
+		t.Fatalf("expected New to span %d tokens, got %d", wantTokens, newFunc.Tokens)
+	}
+	if newFunc.Location() != "shapes/shapes.go:30" {
+		t.Fatalf("unexpected location %s", newFunc.Location())
+	}
+}
+
+func TestRun_SortsByTokens(t *testing.T) {
+	result, err := Run(Options{
+		Files:     []count.FileStat{{Path: "a.go", Language: "Go"}},
+		Read:      func(string) ([]byte, error) { return []byte(goSource), nil },
+		Tokenizer: byteTokenizer{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(result.Symbols); i++ {
+		if result.Symbols[i-1].Tokens < result.Symbols[i].Tokens {
+			t.Fatalf("expected symbols sorted by tokens, got %+v", result.Symbols)
+		}
+	}
+
+	if _, err := Run(Options{Read: func(string) ([]byte, error) { return nil, nil }, Tokenizer: byteTokenizer{}, Sort: "size"}); err == nil {
+		t.Fatal("expected an unknown sort order to fail")
+	}
+}
@@ -0,0 +1,77 @@
+package symbols
+
+import "regexp"
+
+var (
+	tsDecorators = `^\s*(?:@[\w$.]+(?:\([^)]*\))?\s*)*`
+	tsClass      = regexp.MustCompile(tsDecorators + `(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\b\s*([\w$]*)`)
+	tsInterface  = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?interface\s+([\w$]+)`)
+	tsEnum       = regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+([\w$]+)`)
+	tsFunction   = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\b\s*\*?\s*([\w$]*)`)
+	// tsArrow is a variable bound to a function: an arrow on the same line,
+	// a function expression, or parameters opening a multi-line list.
+	tsArrow     = regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:const|let|var)\s+([\w$]+)\s*(?::[^=]+)?=\s*(?:async\b\s*)?(?:function\b|[^=]*=>|(?:<[^>]*>)?\(\s*$)`)
+	tsTypeAlias = regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?type\s+([\w$]+)`)
+	tsNamespace = regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:namespace|module)\s+([\w$.]+)`)
+	tsModifiers = `(?:(?:public|private|protected|static|readonly|abstract|override|async|declare|accessor)\s+)*`
+	tsMethod    = regexp.MustCompile(tsDecorators + tsModifiers + `(?:(?:get|set)\s+)?\*?\s*(#?[\w$]+)\s*\??\s*(?:<[^>]*>)?\s*\(`)
+	tsProperty  = regexp.MustCompile(tsDecorators + tsModifiers + `(#?[\w$]+)\s*\??\s*(?::[^=]+)?=\s*(?:async\b\s*)?(?:function\b|[^=]*=>)`)
+)
+
+// tsKeywords look like method calls in a class body but are not members.
+var tsKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "new": true, "function": true, "super": true, "this": true,
+}
+
+// typeScript outlines TypeScript and JavaScript: functions, classes and
+// their methods, interfaces, enums, type aliases and namespaces.
+var typeScript = braceLang{
+	sep: ".",
+	match: func(line string, in scope, body bool, first bool) (decl, bool) {
+		if in.kind == KindClass {
+			for _, re := range []*regexp.Regexp{tsMethod, tsProperty} {
+				if m := re.FindStringSubmatch(line); m != nil && !tsKeywords[m[1]] {
+					return decl{name: m[1], kind: KindMethod}, true
+				}
+			}
+			return decl{}, false
+		}
+		if m := tsClass.FindStringSubmatch(line); m != nil {
+			return decl{name: defaultName(m[1]), kind: KindClass, descend: true}, true
+		}
+		if m := tsFunction.FindStringSubmatch(line); m != nil {
+			return decl{name: defaultName(m[1]), kind: KindFunc}, true
+		}
+		if m := tsArrow.FindStringSubmatch(line); m != nil {
+			return decl{name: m[1], kind: KindFunc}, true
+		}
+		if m := tsInterface.FindStringSubmatch(line); m != nil {
+			return decl{name: m[1], kind: KindInterface}, true
+		}
+		if m := tsEnum.FindStringSubmatch(line); m != nil {
+			return decl{name: m[1], kind: KindEnum}, true
+		}
+		if m := tsTypeAlias.FindStringSubmatch(line); m != nil {
+			return decl{name: m[1], kind: KindType}, true
+		}
+		if m := tsNamespace.FindStringSubmatch(line); m != nil && body {
+			return decl{name: m[1], kind: KindModule, descend: true}, true
+		}
+		return decl{}, false
+	},
+}
+
+// defaultName names anonymous default exports.
+func defaultName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+func parseTypeScript(path string, src []byte) ([]span, error) {
+	o := newOutline("TypeScript", src)
+	o.braces(typeScript, 0, len(src), scope{})
+	return o.spans, nil
+}
@@ -0,0 +1,93 @@
+package tokenizer
+
+import "container/heap"
+
+// bpeMerge is the result of merging a symbol pair: its priority and the id
+// of the merged symbol.
+type bpeMerge struct {
+	rank int
+	id   int
+}
+
+// mergeSymbols repeatedly merges the adjacent pair with the lowest rank
+// (leftmost on ties) until lookup finds no more merges. Symbols are kept in
+// a linked list so long words stay O(n log n).
+func mergeSymbols(ids []int, lookup func(pair [2]int) (bpeMerge, bool)) []int {
+	if len(ids) < 2 {
+		return ids
+	}
+	type symbol struct {
+		id, prev, next int
+		removed        bool
+	}
+	symbols := make([]symbol, len(ids))
+	for i, id := range ids {
+		symbols[i] = symbol{id: id, prev: i - 1, next: i + 1}
+	}
+	symbols[len(symbols)-1].next = -1
+
+	queue := &bpeQueue{}
+	push := func(pos int) {
+		next := symbols[pos].next
+		if next < 0 {
+			return
+		}
+		pair := [2]int{symbols[pos].id, symbols[next].id}
+		if merge, ok := lookup(pair); ok {
+			heap.Push(queue, bpeCandidate{rank: merge.rank, pos: pos, pair: pair})
+		}
+	}
+	for i := range symbols[:len(symbols)-1] {
+		push(i)
+	}
+
+	for queue.Len() > 0 {
+		candidate := heap.Pop(queue).(bpeCandidate)
+		left := &symbols[candidate.pos]
+		if left.removed || left.id != candidate.pair[0] || left.next < 0 || symbols[left.next].id != candidate.pair[1] {
+			continue // stale: one side was merged away since this was queued
+		}
+		right := left.next
+		merge, _ := lookup(candidate.pair)
+		left.id = merge.id
+		left.next = symbols[right].next
+		if left.next >= 0 {
+			symbols[left.next].prev = candidate.pos
+		}
+		symbols[right].removed = true
+		if left.prev >= 0 {
+			push(left.prev)
+		}
+		push(candidate.pos)
+	}
+
+	out := make([]int, 0, len(symbols))
+	for i := 0; i >= 0; i = symbols[i].next {
+		out = append(out, symbols[i].id)
+	}
+	return out
+}
+
+type bpeCandidate struct {
+	rank int
+	pos  int
+	pair [2]int
+}
+
+type bpeQueue []bpeCandidate
+
+func (q bpeQueue) Len() int { return len(q) }
+func (q bpeQueue) Less(i, j int) bool {
+	if q[i].rank == q[j].rank {
+		return q[i].pos < q[j].pos
+	}
+	return q[i].rank < q[j].rank
+}
+func (q bpeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *bpeQueue) Push(x any)   { *q = append(*q, x.(bpeCandidate)) }
+func (q *bpeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
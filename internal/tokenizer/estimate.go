@@ -1,25 +1,120 @@
 package tokenizer
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
 
-// EstimateTokenizer uses a characters-per-token heuristic.
+// DefaultCharsPerToken is the ratio used for text with no table entry.
+const DefaultCharsPerToken = 3.5
+
+// defaultRatios are chars/token ratios by file extension, measured against
+// cl100k_base on open-source code, docs and data files. Multi-part suffixes
+// such as ".min.js" take precedence over their last extension.
+var defaultRatios = map[string]float64{
+	// Systems and compiled languages.
+	".go": 3.1, ".c": 3.6, ".h": 3.7, ".cc": 3.8, ".cpp": 3.8, ".cxx": 3.8, ".hpp": 3.9,
+	".rs": 3.4, ".java": 4.0, ".kt": 3.8, ".scala": 3.7, ".cs": 4.0, ".swift": 3.7,
+	".dart": 3.8, ".zig": 3.4, ".s": 2.3, ".asm": 2.3,
+	// Scripting languages.
+	".py": 4.2, ".pyi": 3.7, ".rb": 3.7, ".php": 3.5, ".pl": 3.0, ".pm": 3.6, ".lua": 3.5,
+	".r": 3.4, ".ex": 3.6, ".exs": 3.6, ".erl": 3.3, ".hs": 3.3, ".ml": 3.3, ".clj": 3.3,
+	".sh": 3.2, ".bash": 3.2, ".zsh": 3.2, ".fish": 3.2, ".ps1": 3.4, ".bat": 3.3, ".awk": 3.1,
+	// Web.
+	".js": 3.6, ".mjs": 3.6, ".cjs": 3.6, ".jsx": 3.5, ".ts": 3.6, ".tsx": 3.5,
+	".vue": 3.4, ".svelte": 3.4, ".html": 3.4, ".htm": 3.4, ".css": 3.2, ".scss": 3.2,
+	".less": 3.2, ".svg": 2.5, ".min.js": 2.6, ".min.css": 2.6,
+	// Data and configuration.
+	".json": 2.6, ".jsonl": 2.6, ".ipynb": 2.8, ".yaml": 2.8, ".yml": 2.8, ".toml": 3.0,
+	".xml": 3.5, ".csv": 2.4, ".tsv": 2.4, ".ini": 3.4, ".cfg": 3.4, ".conf": 3.4,
+	".env": 3.0, ".proto": 3.6, ".graphql": 3.4, ".sql": 3.4, ".tf": 3.2, ".mod": 2.5,
+	".sum": 1.7, ".pem": 1.5,
+	// Prose.
+	".md": 3.8, ".mdx": 3.7, ".rst": 4.2, ".txt": 3.6, ".adoc": 3.9, ".tex": 3.4,
+}
+
+// DefaultRatios returns a copy of the built-in chars/token table.
+func DefaultRatios() map[string]float64 {
+	out := make(map[string]float64, len(defaultRatios))
+	for ext, ratio := range defaultRatios {
+		out[ext] = ratio
+	}
+	return out
+}
+
+// EstimateTokenizer uses a characters-per-token heuristic. With a ratio
+// table it picks the ratio by the file extension of each counted file.
 type EstimateTokenizer struct {
 	charsPerToken float64
+	ratios        map[string]float64
+	overrides     int
 }
 
-// NewEstimate creates the chars/token estimator.
+// NewEstimate creates a flat chars/token estimator.
 func NewEstimate(charsPerToken float64) *EstimateTokenizer {
 	if charsPerToken <= 0 {
-		charsPerToken = 3.5
+		charsPerToken = DefaultCharsPerToken
 	}
 	return &EstimateTokenizer{charsPerToken: charsPerToken}
 }
 
+// NewCalibratedEstimate creates an estimator that uses the built-in
+// per-extension table, with overrides layered on top. charsPerToken is the
+// fallback for unlisted extensions (<= 0 uses DefaultCharsPerToken).
+func NewCalibratedEstimate(charsPerToken float64, overrides map[string]float64) (*EstimateTokenizer, error) {
+	t := NewEstimate(charsPerToken)
+	t.ratios = DefaultRatios()
+	for ext, ratio := range overrides {
+		if ratio <= 0 {
+			return nil, fmt.Errorf("estimate ratio for %s must be positive, got %g", ext, ratio)
+		}
+		t.ratios[normalizeExtension(ext)] = ratio
+	}
+	t.overrides = len(overrides)
+	return t, nil
+}
+
 func (t *EstimateTokenizer) Count(text string) int {
-	if text == "" {
+	return estimateTokens(int64(len(text)), t.charsPerToken)
+}
+
+// CountPath estimates tokens for text read from path using the ratio of
+// its extension.
+func (t *EstimateTokenizer) CountPath(path string, text string) int {
+	return estimateTokens(int64(len(text)), t.CharsPerToken(path))
+}
+
+// CountBytes estimates tokens for n bytes of text without reading it.
+func (t *EstimateTokenizer) CountBytes(n int64) int {
+	return estimateTokens(n, t.charsPerToken)
+}
+
+// CharsPerToken returns the ratio applied to files at path.
+func (t *EstimateTokenizer) CharsPerToken(path string) float64 {
+	if len(t.ratios) == 0 {
+		return t.charsPerToken
+	}
+	// Scanning from the left tries the longest dotted suffix first, so
+	// "app.min.js" matches ".min.js" before ".js".
+	base := strings.ToLower(filepath.Base(path))
+	for i := 0; i < len(base); i++ {
+		if base[i] != '.' {
+			continue
+		}
+		if ratio, ok := t.ratios[base[i:]]; ok {
+			return ratio
+		}
+	}
+	return t.charsPerToken
+}
+
+func estimateTokens(chars int64, charsPerToken float64) int {
+	if chars <= 0 {
 		return 0
 	}
-	return int(math.Round(float64(len(text)) / t.charsPerToken))
+	return int(math.Round(float64(chars) / charsPerToken))
 }
 
 func (t *EstimateTokenizer) Name() string {
@@ -27,5 +122,20 @@ func (t *EstimateTokenizer) Name() string {
 }
 
 func (t *EstimateTokenizer) Description() string {
-	return "estimate (chars / 3.5)"
+	if len(t.ratios) == 0 {
+		return fmt.Sprintf("estimate (chars / %g)", t.charsPerToken)
+	}
+	if t.overrides > 0 {
+		return fmt.Sprintf("estimate (chars/token by extension, %d overrides, default %g)", t.overrides, t.charsPerToken)
+	}
+	return fmt.Sprintf("estimate (chars/token by extension, default %g)", t.charsPerToken)
+}
+
+// normalizeExtension lowercases ext and ensures a leading dot.
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
 }
@@ -1,6 +1,11 @@
 package tokenizer
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestEstimateTokenizer_Count(t *testing.T) {
 	tok := NewEstimate(3.5)
@@ -21,3 +26,88 @@ func TestFactory_DefaultEstimate(t *testing.T) {
 		t.Fatalf("expected estimate tokenizer, got %s", tok.Name())
 	}
 }
+
+func TestCalibratedEstimate_RatioByExtension(t *testing.T) {
+	tok, err := NewCalibratedEstimate(0, map[string]float64{"go": 2.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Repeat("x", 100)
+
+	if got := tok.CountPath("cmd/main.go", text); got != 50 {
+		t.Fatalf("expected override ratio for .go, got %d tokens", got)
+	}
+	if got := tok.CountPath("web/app.min.js", text); got != 38 {
+		t.Fatalf("expected .min.js ratio to win over .js, got %d tokens", got)
+	}
+	if got := tok.CountPath("LICENSE", text); got != 29 {
+		t.Fatalf("expected default ratio for unlisted files, got %d tokens", got)
+	}
+	if _, err := NewCalibratedEstimate(0, map[string]float64{".go": 0}); err == nil {
+		t.Fatalf("expected error for a non-positive ratio")
+	}
+	if got := NewEstimate(4).Description(); got != "estimate (chars / 4)" {
+		t.Fatalf("expected description to report the ratio, got %q", got)
+	}
+}
+
+func TestFactory_EstimateRatioFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratios.yaml")
+	want := RatioFile{Tokenizer: "cl100k_base (GPT-4)", CharsPerToken: 4, Ratios: map[string]float64{".go": 2}}
+	payload, err := want.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := NewWithOptions("estimate", Options{RatioFile: path, EstimateRatios: map[string]float64{".py": 5}})
+	if err != nil {
+		t.Fatalf("expected ratio file to load, got %v", err)
+	}
+	text := strings.Repeat("x", 100)
+	for path, tokens := range map[string]int{"main.go": 50, "app.py": 20, "notes.xyz": 25} {
+		if got := CountFile(tok, path, text); got != tokens {
+			t.Fatalf("%s: expected %d tokens, got %d", path, tokens, got)
+		}
+	}
+}
+
+func TestNewList(t *testing.T) {
+	toks, err := NewList("estimate, openai", Options{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(toks) != 2 || toks[0].Name() != "estimate" || toks[1].Name() != "openai" {
+		t.Fatalf("expected estimate then openai, got %v", toks)
+	}
+
+	for _, names := range []string{"openai,claude,openai", "openai,", "estimate,nope"} {
+		if _, err := NewList(names, Options{}); err == nil {
+			t.Fatalf("expected %q to be rejected", names)
+		}
+	}
+	if _, err := NewList("openai,openai-o200k", Options{RankFile: "ranks.tiktoken"}); err == nil {
+		t.Fatal("expected a tokenizer file to be rejected for a list")
+	}
+}
+
+func TestNewList_FileTokenizersByEntry(t *testing.T) {
+	names := "hf:testdata/gpt2-style.json,hf:testdata/mistral-style.json,spm:testdata/unigram.model,spm:testdata/bpe.model"
+	toks, err := NewList(names, Options{})
+	if err != nil {
+		t.Fatalf("expected two hf and two spm files to be accepted, got %v", err)
+	}
+	var labels []string
+	for _, tok := range toks {
+		labels = append(labels, Label(tok))
+	}
+	if got := strings.Join(labels, ","); got != names {
+		t.Fatalf("expected labels %s, got %s", names, got)
+	}
+
+	if _, err := NewList("hf:testdata/gpt2-style.json,hf:testdata/gpt2-style.json", Options{}); err == nil {
+		t.Fatal("expected the same file listed twice to be rejected")
+	}
+}
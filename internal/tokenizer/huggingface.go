@@ -0,0 +1,846 @@
+package tokenizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dlclark/regexp2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// gpt2SplitPattern is the split regex the ByteLevel pre-tokenizer applies
+// when use_regex is set.
+const gpt2SplitPattern = `'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`
+
+// wordCacheLimit bounds the per-tokenizer cache of encoded words.
+const wordCacheLimit = 1 << 16
+
+// HuggingFaceTokenizer counts tokens with a BPE model loaded from a Hugging
+// Face tokenizer.json file (Llama, Mistral, Qwen and similar models).
+//
+// It runs the file's added-token split, normalizer, pre-tokenizer and BPE
+// merges. Post-processors (BOS/EOS templates) are not applied, so counts
+// cover the text only.
+type HuggingFaceTokenizer struct {
+	path        string
+	description string
+	normalize   hfNormalizer
+	preTokenize hfPreTokenizer
+	added       *regexp.Regexp
+	addedIDs    map[string]int
+	model       *hfBPE
+	byteLevel   bool
+	metaspace   string
+	tokensByID  map[int]string
+	wordCacheMu sync.RWMutex
+	wordCache   map[string][]int
+}
+
+// hfNormalizer rewrites text before pre-tokenization.
+type hfNormalizer func(text string) string
+
+// hfPreTokenizer splits pieces further; atStart reports whether pieces[0]
+// begins the original text.
+type hfPreTokenizer func(pieces []string, atStart bool) []string
+
+// hfPattern returns the byte ranges a Split or Replace pattern matches.
+type hfPattern func(text string) [][2]int
+
+type hfFile struct {
+	AddedTokens []struct {
+		ID      int    `json:"id"`
+		Content string `json:"content"`
+	} `json:"added_tokens"`
+	Normalizer   json.RawMessage `json:"normalizer"`
+	PreTokenizer json.RawMessage `json:"pre_tokenizer"`
+	Decoder      json.RawMessage `json:"decoder"`
+	Model        hfModel         `json:"model"`
+}
+
+type hfModel struct {
+	Type                    string            `json:"type"`
+	Vocab                   map[string]int    `json:"vocab"`
+	Merges                  []json.RawMessage `json:"merges"`
+	UnkToken                *string           `json:"unk_token"`
+	FuseUnk                 bool              `json:"fuse_unk"`
+	ByteFallback            bool              `json:"byte_fallback"`
+	IgnoreMerges            bool              `json:"ignore_merges"`
+	ContinuingSubwordPrefix *string           `json:"continuing_subword_prefix"`
+	EndOfWordSuffix         *string           `json:"end_of_word_suffix"`
+}
+
+// hfComponent is the union of the normalizer and pre-tokenizer settings
+// tokcount understands; Type selects which fields apply.
+type hfComponent struct {
+	Type          string            `json:"type"`
+	Normalizers   []json.RawMessage `json:"normalizers"`
+	PreTokenizers []json.RawMessage `json:"pretokenizers"`
+	Decoders      []json.RawMessage `json:"decoders"`
+
+	AddPrefixSpace *bool `json:"add_prefix_space"`
+	UseRegex       *bool `json:"use_regex"`
+
+	Pattern struct {
+		String *string `json:"String"`
+		Regex  *string `json:"Regex"`
+	} `json:"pattern"`
+	Behavior string `json:"behavior"`
+	Invert   bool   `json:"invert"`
+	Content  string `json:"content"`
+
+	Replacement   string `json:"replacement"`
+	PrependScheme string `json:"prepend_scheme"`
+	Split         *bool  `json:"split"`
+
+	Prepend          string `json:"prepend"`
+	StripLeft        bool   `json:"strip_left"`
+	StripRight       bool   `json:"strip_right"`
+	IndividualDigits bool   `json:"individual_digits"`
+}
+
+// NewHuggingFace loads a BPE tokenizer from a tokenizer.json file. The file
+// hash is part of the description so cached counts follow the file.
+func NewHuggingFace(path string) (*HuggingFaceTokenizer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tokenizer file: %w", err)
+	}
+	var file hfFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse tokenizer file %s: %w", path, err)
+	}
+
+	t := &HuggingFaceTokenizer{
+		path:       filepath.ToSlash(path),
+		addedIDs:   make(map[string]int),
+		tokensByID: make(map[int]string),
+		wordCache:  make(map[string][]int),
+	}
+	if t.model, err = newHFBPE(file.Model); err != nil {
+		return nil, fmt.Errorf("parse tokenizer file %s: %w", path, err)
+	}
+	if t.normalize, err = t.buildNormalizer(file.Normalizer); err != nil {
+		return nil, fmt.Errorf("parse tokenizer file %s: normalizer: %w", path, err)
+	}
+	if t.preTokenize, err = t.buildPreTokenizer(file.PreTokenizer); err != nil {
+		return nil, fmt.Errorf("parse tokenizer file %s: pre_tokenizer: %w", path, err)
+	}
+	if err := t.readDecoder(file.Decoder); err != nil {
+		return nil, fmt.Errorf("parse tokenizer file %s: decoder: %w", path, err)
+	}
+
+	for token, id := range file.Model.Vocab {
+		t.tokensByID[id] = token
+	}
+	contents := make([]string, 0, len(file.AddedTokens))
+	for _, added := range file.AddedTokens {
+		if added.Content == "" {
+			continue
+		}
+		t.addedIDs[added.Content] = added.ID
+		t.tokensByID[added.ID] = added.Content
+		contents = append(contents, added.Content)
+	}
+	if len(contents) > 0 {
+		// Longest first so overlapping added tokens prefer the longer one.
+		sort.Slice(contents, func(i, j int) bool { return len(contents[i]) > len(contents[j]) })
+		for i := range contents {
+			contents[i] = regexp.QuoteMeta(contents[i])
+		}
+		t.added = regexp.MustCompile(strings.Join(contents, "|"))
+	}
+
+	sum := sha256.Sum256(raw)
+	label := filepath.Join(filepath.Base(filepath.Dir(path)), filepath.Base(path))
+	t.description = fmt.Sprintf("%s BPE (sha256 %s)", filepath.ToSlash(label), hex.EncodeToString(sum[:6]))
+	return t, nil
+}
+
+func (t *HuggingFaceTokenizer) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	total := 0
+	t.encode(text, func(ids []int) { total += len(ids) })
+	return total
+}
+
+// Encode returns the token ids for text.
+func (t *HuggingFaceTokenizer) Encode(text string) []int {
+	var out []int
+	t.encode(text, func(ids []int) { out = append(out, ids...) })
+	return out
+}
+
+// Decode maps token ids back to text, reversing the byte-level alphabet,
+// byte-fallback tokens and the metaspace replacement. Prefix spaces added
+// during encoding are kept.
+func (t *HuggingFaceTokenizer) Decode(ids []int) string {
+	var out []byte
+	for _, id := range ids {
+		token, ok := t.tokensByID[id]
+		if !ok {
+			continue
+		}
+		if _, added := t.addedIDs[token]; added {
+			out = append(out, token...)
+			continue
+		}
+		if b, ok := byteFallbackValue(token); ok && t.model.byteFallback {
+			out = append(out, b)
+			continue
+		}
+		if t.byteLevel {
+			for _, r := range token {
+				if b, ok := byteLevelDecoder[r]; ok {
+					out = append(out, b)
+				} else {
+					out = utf8.AppendRune(out, r)
+				}
+			}
+			continue
+		}
+		if t.metaspace != "" {
+			token = strings.ReplaceAll(token, t.metaspace, " ")
+		}
+		out = append(out, token...)
+	}
+	return string(out)
+}
+
+func (t *HuggingFaceTokenizer) Name() string {
+	return "hf"
+}
+
+func (t *HuggingFaceTokenizer) Description() string {
+	return t.description
+}
+
+// Label is the list entry that loads the file.
+func (t *HuggingFaceTokenizer) Label() string {
+	return "hf:" + t.path
+}
+
+// encode splits out added tokens, then normalizes, pre-tokenizes and
+// BPE-encodes the text between them, passing each word's ids to emit.
+func (t *HuggingFaceTokenizer) encode(text string, emit func(ids []int)) {
+	prev := 0
+	if t.added != nil {
+		for _, span := range t.added.FindAllStringIndex(text, -1) {
+			t.encodeSegment(text[prev:span[0]], prev == 0, emit)
+			emit([]int{t.addedIDs[text[span[0]:span[1]]]})
+			prev = span[1]
+		}
+	}
+	t.encodeSegment(text[prev:], prev == 0, emit)
+}
+
+func (t *HuggingFaceTokenizer) encodeSegment(segment string, atStart bool, emit func(ids []int)) {
+	if segment == "" {
+		return
+	}
+	if t.normalize != nil {
+		segment = t.normalize(segment)
+	}
+	pieces := []string{segment}
+	if t.preTokenize != nil {
+		pieces = t.preTokenize(pieces, atStart)
+	}
+	for _, word := range pieces {
+		if word != "" {
+			emit(t.encodeWord(word))
+		}
+	}
+}
+
+func (t *HuggingFaceTokenizer) encodeWord(word string) []int {
+	t.wordCacheMu.RLock()
+	ids, ok := t.wordCache[word]
+	t.wordCacheMu.RUnlock()
+	if ok {
+		return ids
+	}
+
+	ids = t.model.encode(word)
+
+	t.wordCacheMu.Lock()
+	if len(t.wordCache) >= wordCacheLimit {
+		t.wordCache = make(map[string][]int)
+	}
+	t.wordCache[word] = ids
+	t.wordCacheMu.Unlock()
+	return ids
+}
+
+// readDecoder picks up the byte-level and metaspace settings Decode needs
+// from the decoder section; other decoder steps are ignored.
+func (t *HuggingFaceTokenizer) readDecoder(raw json.RawMessage) error {
+	if isJSONNull(raw) {
+		return nil
+	}
+	var c hfComponent
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return err
+	}
+	switch c.Type {
+	case "Sequence":
+		for _, child := range c.Decoders {
+			if err := t.readDecoder(child); err != nil {
+				return err
+			}
+		}
+	case "ByteLevel":
+		t.byteLevel = true
+	case "Metaspace":
+		t.metaspace = c.Replacement
+		if t.metaspace == "" {
+			t.metaspace = "▁"
+		}
+	case "Replace":
+		if c.Pattern.String != nil && c.Content == " " {
+			t.metaspace = *c.Pattern.String
+		}
+	}
+	return nil
+}
+
+func (t *HuggingFaceTokenizer) buildNormalizer(raw json.RawMessage) (hfNormalizer, error) {
+	if isJSONNull(raw) {
+		return nil, nil
+	}
+	var c hfComponent
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+	switch c.Type {
+	case "Sequence":
+		steps := make([]hfNormalizer, 0, len(c.Normalizers))
+		for _, child := range c.Normalizers {
+			step, err := t.buildNormalizer(child)
+			if err != nil {
+				return nil, err
+			}
+			if step != nil {
+				steps = append(steps, step)
+			}
+		}
+		return func(text string) string {
+			for _, step := range steps {
+				text = step(text)
+			}
+			return text
+		}, nil
+	case "NFC":
+		return norm.NFC.String, nil
+	case "NFD":
+		return norm.NFD.String, nil
+	case "NFKC":
+		return norm.NFKC.String, nil
+	case "NFKD":
+		return norm.NFKD.String, nil
+	case "Lowercase":
+		return strings.ToLower, nil
+	case "Prepend":
+		prefix := c.Prepend
+		return func(text string) string {
+			if text == "" {
+				return text
+			}
+			return prefix + text
+		}, nil
+	case "Replace":
+		find, err := compileHFPattern(c)
+		if err != nil {
+			return nil, err
+		}
+		content := c.Content
+		return func(text string) string {
+			var b strings.Builder
+			prev := 0
+			for _, span := range find(text) {
+				b.WriteString(text[prev:span[0]])
+				b.WriteString(content)
+				prev = span[1]
+			}
+			b.WriteString(text[prev:])
+			return b.String()
+		}, nil
+	case "Strip":
+		left, right := c.StripLeft, c.StripRight
+		return func(text string) string {
+			if left {
+				text = strings.TrimLeftFunc(text, unicode.IsSpace)
+			}
+			if right {
+				text = strings.TrimRightFunc(text, unicode.IsSpace)
+			}
+			return text
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported normalizer type %q", c.Type)
+	}
+}
+
+func (t *HuggingFaceTokenizer) buildPreTokenizer(raw json.RawMessage) (hfPreTokenizer, error) {
+	if isJSONNull(raw) {
+		return nil, nil
+	}
+	var c hfComponent
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+	switch c.Type {
+	case "Sequence":
+		steps := make([]hfPreTokenizer, 0, len(c.PreTokenizers))
+		for _, child := range c.PreTokenizers {
+			step, err := t.buildPreTokenizer(child)
+			if err != nil {
+				return nil, err
+			}
+			if step != nil {
+				steps = append(steps, step)
+			}
+		}
+		return func(pieces []string, atStart bool) []string {
+			for _, step := range steps {
+				pieces = step(pieces, atStart)
+			}
+			return pieces
+		}, nil
+	case "ByteLevel":
+		t.byteLevel = true
+		addPrefixSpace := c.AddPrefixSpace == nil || *c.AddPrefixSpace
+		var split hfPattern
+		if c.UseRegex == nil || *c.UseRegex {
+			split = regexpPattern(regexp2.MustCompile(gpt2SplitPattern, regexp2.None))
+		}
+		return eachPiece(func(piece string, _ bool) []string {
+			if addPrefixSpace && !strings.HasPrefix(piece, " ") {
+				piece = " " + piece
+			}
+			parts := []string{piece}
+			if split != nil {
+				parts = splitByPattern(piece, split, "Isolated", false)
+			}
+			for i, part := range parts {
+				parts[i] = byteLevelEncode(part)
+			}
+			return parts
+		}), nil
+	case "Split":
+		find, err := compileHFPattern(c)
+		if err != nil {
+			return nil, err
+		}
+		behavior, invert := c.Behavior, c.Invert
+		return eachPiece(func(piece string, _ bool) []string {
+			return splitByPattern(piece, find, behavior, invert)
+		}), nil
+	case "Metaspace":
+		replacement := c.Replacement
+		if replacement == "" {
+			replacement = "▁"
+		}
+		t.metaspace = replacement
+		scheme := c.PrependScheme
+		if scheme == "" {
+			scheme = "always"
+			if c.AddPrefixSpace != nil && !*c.AddPrefixSpace {
+				scheme = "never"
+			}
+		}
+		split := c.Split == nil || *c.Split
+		find := literalPattern(replacement)
+		return eachPiece(func(piece string, first bool) []string {
+			piece = strings.ReplaceAll(piece, " ", replacement)
+			prepend := scheme == "always" || (scheme == "first" && first)
+			if prepend && !strings.HasPrefix(piece, replacement) {
+				piece = replacement + piece
+			}
+			if !split {
+				return []string{piece}
+			}
+			return splitByPattern(piece, find, "MergedWithNext", false)
+		}), nil
+	case "Whitespace":
+		find := regexpPattern(regexp2.MustCompile(`\w+|[^\w\s]+`, regexp2.None))
+		return eachPiece(func(piece string, _ bool) []string {
+			return splitByPattern(piece, find, "Removed", true)
+		}), nil
+	case "WhitespaceSplit":
+		return eachPiece(func(piece string, _ bool) []string {
+			return strings.Fields(piece)
+		}), nil
+	case "Digits":
+		behavior := "Contiguous"
+		if c.IndividualDigits {
+			behavior = "Isolated"
+		}
+		find := runePattern(unicode.IsNumber)
+		return eachPiece(func(piece string, _ bool) []string {
+			return splitByPattern(piece, find, behavior, false)
+		}), nil
+	case "Punctuation":
+		behavior := c.Behavior
+		if behavior == "" {
+			behavior = "Isolated"
+		}
+		find := runePattern(func(r rune) bool { return unicode.IsPunct(r) || (r < utf8.RuneSelf && unicode.IsSymbol(r)) })
+		return eachPiece(func(piece string, _ bool) []string {
+			return splitByPattern(piece, find, behavior, false)
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported pre-tokenizer type %q", c.Type)
+	}
+}
+
+// eachPiece lifts a per-piece split into an hfPreTokenizer.
+func eachPiece(split func(piece string, first bool) []string) hfPreTokenizer {
+	return func(pieces []string, atStart bool) []string {
+		out := make([]string, 0, len(pieces))
+		for i, piece := range pieces {
+			out = append(out, split(piece, atStart && i == 0)...)
+		}
+		return out
+	}
+}
+
+// splitByPattern splits text around pattern matches the way the Hugging
+// Face SplitDelimiterBehavior variants do.
+func splitByPattern(text string, find hfPattern, behavior string, invert bool) []string {
+	type segment struct {
+		start, end int
+		match      bool
+	}
+	var segments []segment
+	prev := 0
+	for _, span := range find(text) {
+		if span[0] > prev {
+			segments = append(segments, segment{prev, span[0], false})
+		}
+		if span[1] > span[0] {
+			segments = append(segments, segment{span[0], span[1], true})
+		}
+		prev = span[1]
+	}
+	if prev < len(text) {
+		segments = append(segments, segment{prev, len(text), false})
+	}
+	if invert {
+		for i := range segments {
+			segments[i].match = !segments[i].match
+		}
+	}
+
+	var merged []segment
+	switch behavior {
+	case "Removed":
+		for _, seg := range segments {
+			if !seg.match {
+				merged = append(merged, seg)
+			}
+		}
+	case "MergedWithPrevious":
+		previousMatch := false
+		for _, seg := range segments {
+			if seg.match && !previousMatch && len(merged) > 0 {
+				merged[len(merged)-1].end = seg.end
+			} else {
+				merged = append(merged, seg)
+			}
+			previousMatch = seg.match
+		}
+	case "MergedWithNext":
+		previousMatch := false
+		for i := len(segments) - 1; i >= 0; i-- {
+			seg := segments[i]
+			if seg.match && !previousMatch && len(merged) > 0 {
+				merged[len(merged)-1].start = seg.start
+			} else {
+				merged = append(merged, seg)
+			}
+			previousMatch = seg.match
+		}
+		for i, j := 0, len(merged)-1; i < j; i, j = i+1, j-1 {
+			merged[i], merged[j] = merged[j], merged[i]
+		}
+	case "Contiguous":
+		for i, seg := range segments {
+			if i > 0 && seg.match == segments[i-1].match {
+				merged[len(merged)-1].end = seg.end
+			} else {
+				merged = append(merged, seg)
+			}
+		}
+	default: // Isolated
+		merged = segments
+	}
+
+	out := make([]string, 0, len(merged))
+	for _, seg := range merged {
+		out = append(out, text[seg.start:seg.end])
+	}
+	return out
+}
+
+func compileHFPattern(c hfComponent) (hfPattern, error) {
+	switch {
+	case c.Pattern.String != nil:
+		if *c.Pattern.String == "" {
+			return nil, fmt.Errorf("%s: empty pattern", c.Type)
+		}
+		return literalPattern(*c.Pattern.String), nil
+	case c.Pattern.Regex != nil:
+		re, err := regexp2.Compile(*c.Pattern.Regex, regexp2.None)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Type, err)
+		}
+		return regexpPattern(re), nil
+	default:
+		return nil, fmt.Errorf("%s: missing pattern", c.Type)
+	}
+}
+
+func literalPattern(literal string) hfPattern {
+	return func(text string) [][2]int {
+		var spans [][2]int
+		offset := 0
+		for {
+			i := strings.Index(text[offset:], literal)
+			if i < 0 {
+				return spans
+			}
+			start := offset + i
+			offset = start + len(literal)
+			spans = append(spans, [2]int{start, offset})
+		}
+	}
+}
+
+// regexpPattern adapts a regexp2 expression, which reports rune offsets, to
+// byte ranges of the original text.
+func regexpPattern(re *regexp2.Regexp) hfPattern {
+	return func(text string) [][2]int {
+		offsets := make([]int, 0, len(text)+1)
+		for i := range text {
+			offsets = append(offsets, i)
+		}
+		offsets = append(offsets, len(text))
+
+		var spans [][2]int
+		match, _ := re.FindRunesMatch([]rune(text))
+		for match != nil {
+			spans = append(spans, [2]int{offsets[match.Index], offsets[match.Index+match.Length]})
+			match, _ = re.FindNextMatch(match)
+		}
+		return spans
+	}
+}
+
+// runePattern matches every rune for which keep is true, one rune per match.
+func runePattern(keep func(rune) bool) hfPattern {
+	return func(text string) [][2]int {
+		var spans [][2]int
+		for i, r := range text {
+			if keep(r) {
+				spans = append(spans, [2]int{i, i + utf8.RuneLen(r)})
+			}
+		}
+		return spans
+	}
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	trimmed := strings.TrimSpace(string(raw))
+	return trimmed == "" || trimmed == "null"
+}
+
+// byteLevelEncoder is the GPT-2 byte-to-unicode alphabet: printable bytes
+// map to themselves, the rest to code points from U+0100 upward.
+var byteLevelEncoder, byteLevelDecoder = func() ([256]rune, map[rune]byte) {
+	var encoder [256]rune
+	decoder := make(map[rune]byte, 256)
+	next := rune(256)
+	for b := 0; b < 256; b++ {
+		if (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF) {
+			encoder[b] = rune(b)
+		} else {
+			encoder[b] = next
+			next++
+		}
+		decoder[encoder[b]] = byte(b)
+	}
+	return encoder, decoder
+}()
+
+func byteLevelEncode(text string) string {
+	var b strings.Builder
+	b.Grow(len(text) * 2)
+	for i := 0; i < len(text); i++ {
+		b.WriteRune(byteLevelEncoder[text[i]])
+	}
+	return b.String()
+}
+
+// byteFallbackValue parses a "<0xAB>" byte-fallback token.
+func byteFallbackValue(token string) (byte, bool) {
+	if len(token) != 6 || !strings.HasPrefix(token, "<0x") || token[5] != '>' {
+		return 0, false
+	}
+	decoded, err := hex.DecodeString(token[3:5])
+	if err != nil {
+		return 0, false
+	}
+	return decoded[0], true
+}
+
+// hfBPE is the BPE model section of a tokenizer.json file.
+type hfBPE struct {
+	vocab        map[string]int
+	merges       map[[2]int]bpeMerge
+	unkID        int
+	fuseUnk      bool
+	byteFallback bool
+	ignoreMerges bool
+	prefix       string
+	suffix       string
+}
+
+func newHFBPE(model hfModel) (*hfBPE, error) {
+	if model.Type != "" && model.Type != "BPE" {
+		return nil, fmt.Errorf("unsupported model type %q (only BPE is supported)", model.Type)
+	}
+	if len(model.Vocab) == 0 {
+		return nil, fmt.Errorf("model has no vocab")
+	}
+
+	m := &hfBPE{
+		vocab:        model.Vocab,
+		merges:       make(map[[2]int]bpeMerge, len(model.Merges)),
+		unkID:        -1,
+		fuseUnk:      model.FuseUnk,
+		byteFallback: model.ByteFallback,
+		ignoreMerges: model.IgnoreMerges,
+	}
+	if model.UnkToken != nil {
+		if id, ok := model.Vocab[*model.UnkToken]; ok {
+			m.unkID = id
+		}
+	}
+	if model.ContinuingSubwordPrefix != nil {
+		m.prefix = *model.ContinuingSubwordPrefix
+	}
+	if model.EndOfWordSuffix != nil {
+		m.suffix = *model.EndOfWordSuffix
+	}
+
+	for rank, raw := range model.Merges {
+		left, right, err := parseMerge(raw)
+		if err != nil {
+			return nil, fmt.Errorf("merge %d: %w", rank, err)
+		}
+		leftID, okLeft := model.Vocab[left]
+		rightID, okRight := model.Vocab[right]
+		mergedToken := left + strings.TrimPrefix(right, m.prefix)
+		mergedID, okMerged := model.Vocab[mergedToken]
+		if !okLeft || !okRight || !okMerged {
+			return nil, fmt.Errorf("merge %d: %q %q references a token missing from the vocab", rank, left, right)
+		}
+		pair := [2]int{leftID, rightID}
+		if _, exists := m.merges[pair]; !exists {
+			m.merges[pair] = bpeMerge{rank: rank, id: mergedID}
+		}
+	}
+	return m, nil
+}
+
+// parseMerge reads a merge written either as "left right" or ["left", "right"].
+func parseMerge(raw json.RawMessage) (string, string, error) {
+	var pair []string
+	if err := json.Unmarshal(raw, &pair); err == nil {
+		if len(pair) != 2 {
+			return "", "", fmt.Errorf("expected 2 tokens, got %d", len(pair))
+		}
+		return pair[0], pair[1], nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", "", err
+	}
+	left, right, ok := strings.Cut(text, " ")
+	if !ok {
+		return "", "", fmt.Errorf("expected \"<left> <right>\", got %q", text)
+	}
+	return left, right, nil
+}
+
+// encode turns one pre-tokenized word into token ids.
+func (m *hfBPE) encode(word string) []int {
+	if m.ignoreMerges {
+		if id, ok := m.vocab[word]; ok {
+			return []int{id}
+		}
+	}
+
+	ids := make([]int, 0, len(word))
+	lastUnk := false
+	for i, r := range word {
+		symbol := string(r)
+		if i > 0 && m.prefix != "" {
+			symbol = m.prefix + symbol
+		}
+		if i+utf8.RuneLen(r) == len(word) && m.suffix != "" {
+			symbol += m.suffix
+		}
+		if id, ok := m.vocab[symbol]; ok {
+			ids = append(ids, id)
+			lastUnk = false
+			continue
+		}
+		if m.byteFallback {
+			if fallback, ok := m.byteFallbackIDs(string(r)); ok {
+				ids = append(ids, fallback...)
+				lastUnk = false
+				continue
+			}
+		}
+		if m.unkID >= 0 && !(m.fuseUnk && lastUnk) {
+			ids = append(ids, m.unkID)
+		}
+		lastUnk = true
+	}
+	return m.merge(ids)
+}
+
+func (m *hfBPE) byteFallbackIDs(char string) ([]int, bool) {
+	ids := make([]int, 0, len(char))
+	for i := 0; i < len(char); i++ {
+		id, ok := m.vocab[fmt.Sprintf("<0x%02X>", char[i])]
+		if !ok {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// merge applies the tokenizer.json merges to a word's symbols.
+func (m *hfBPE) merge(ids []int) []int {
+	if len(m.merges) == 0 {
+		return ids
+	}
+	return mergeSymbols(ids, func(pair [2]int) (bpeMerge, bool) {
+		merge, ok := m.merges[pair]
+		return merge, ok
+	})
+}
@@ -0,0 +1,64 @@
+package tokenizer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHuggingFace_Fixtures(t *testing.T) {
+	cases := []struct {
+		fixture string
+		text    string
+		want    int
+	}{
+		// ByteLevel with the GPT-2 split regex.
+		{"gpt2-style.json", "the cat sat", 3},
+		{"gpt2-style.json", "the mat", 4},
+		{"gpt2-style.json", "héllo", 5}, // é is two bytes; only "Ã ©" merges them
+		{"gpt2-style.json", "  x", 3},   // "\s+(?!\S)" leaves one space on its own
+		{"gpt2-style.json", "a<|endoftext|>b", 3},
+		// Split regex ahead of a non-splitting ByteLevel, with ignore_merges.
+		{"llama3-style.json", "the cat sat", 3},
+		{"llama3-style.json", "the mat", 2}, // " mat" is in the vocab with no merge path
+		{"llama3-style.json", "12345", 5},   // digits split in runs of three
+		{"llama3-style.json", "<|begin_of_text|>the", 2},
+		// Prepend/Replace normalizers with byte fallback.
+		{"mistral-style.json", "hello world", 2},
+		{"mistral-style.json", "hi✓", 6}, // "i" and "✓" fall back to 1 + 3 byte tokens
+		{"mistral-style.json", "<s>hello", 2},
+	}
+	ref := loadReference(t)
+	for _, tc := range cases {
+		ref.check(t, tc.fixture, tc.text, tc.want)
+		tok, err := New("hf:" + filepath.Join("testdata", tc.fixture))
+		if err != nil {
+			t.Fatalf("load %s: %v", tc.fixture, err)
+		}
+		if got := tok.Count(tc.text); got != tc.want {
+			t.Errorf("%s: Count(%q) = %d, want %d", tc.fixture, tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestHuggingFace_DecodeRoundTrip(t *testing.T) {
+	tok, err := NewHuggingFace(filepath.Join("testdata", "gpt2-style.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "héllo wörld\n\tthe cat<|endoftext|>"
+	if got := tok.Decode(tok.Encode(text)); got != text {
+		t.Fatalf("round trip = %q, want %q", got, text)
+	}
+	if !strings.Contains(tok.Description(), "testdata/gpt2-style.json") {
+		t.Fatalf("expected description to name the file, got %q", tok.Description())
+	}
+
+	spm, err := NewHuggingFace(filepath.Join("testdata", "mistral-style.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spm.Decode(spm.Encode("hello world✓")); got != " hello world✓" {
+		t.Fatalf("metaspace round trip = %q", got)
+	}
+}
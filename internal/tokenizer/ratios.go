@@ -0,0 +1,39 @@
+package tokenizer
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RatioFile is a chars/token table for the estimate tokenizer, as written
+// by tokcount calibrate.
+type RatioFile struct {
+	// Tokenizer describes the reference tokenizer the ratios were fit to.
+	Tokenizer string `yaml:"tokenizer,omitempty"`
+	// CharsPerToken applies to extensions missing from Ratios.
+	CharsPerToken float64            `yaml:"chars_per_token,omitempty"`
+	Ratios        map[string]float64 `yaml:"ratios"`
+}
+
+// LoadRatioFile reads a ratio table written by tokcount calibrate.
+func LoadRatioFile(path string) (RatioFile, error) {
+	var file RatioFile
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("read ratio file: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return file, fmt.Errorf("parse ratio file %s: %w", path, err)
+	}
+	if file.CharsPerToken < 0 {
+		return file, fmt.Errorf("parse ratio file %s: chars_per_token must be positive", path)
+	}
+	return file, nil
+}
+
+// Marshal renders the table in the format LoadRatioFile reads.
+func (f RatioFile) Marshal() ([]byte, error) {
+	return yaml.Marshal(f)
+}
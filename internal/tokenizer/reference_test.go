@@ -0,0 +1,62 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// reference holds counts recorded from the upstream tokenizers and
+// sentencepiece libraries by "python3 testdata/generate.py --reference".
+type reference struct {
+	GeneratedBy string                    `json:"generated_by"`
+	Counts      map[string]map[string]int `json:"counts"`
+}
+
+func loadReference(t *testing.T) reference {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "reference.json"))
+	if err != nil {
+		t.Fatalf("read upstream reference counts (run python3 testdata/generate.py --reference): %v", err)
+	}
+	var ref reference
+	if err := json.Unmarshal(data, &ref); err != nil {
+		t.Fatalf("parse reference.json: %v", err)
+	}
+	return ref
+}
+
+// check fails when want, a hand-derived count for text, disagrees with
+// the upstream count or the text was never counted upstream.
+func (r reference) check(t *testing.T, fixture string, text string, want int) {
+	t.Helper()
+	upstream, ok := r.Counts[fixture][text]
+	if !ok {
+		t.Errorf("%s: %q has no upstream count; add it to REFERENCE_TEXTS in testdata/generate.py", fixture, text)
+		return
+	}
+	if upstream != want {
+		t.Errorf("%s: expected %d tokens for %q, %s gives %d", fixture, want, text, r.GeneratedBy, upstream)
+	}
+}
+
+func TestReferenceCounts(t *testing.T) {
+	ref := loadReference(t)
+	for fixture, counts := range ref.Counts {
+		kind := "hf:"
+		if strings.HasSuffix(fixture, ".model") {
+			kind = "spm:"
+		}
+		tok, err := New(kind + filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatalf("load %s: %v", fixture, err)
+		}
+		for text, want := range counts {
+			if got := tok.Count(text); got != want {
+				t.Errorf("%s: Count(%q) = %d, %s gives %d", fixture, text, got, ref.GeneratedBy, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,663 @@
+package tokenizer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Piece types from sentencepiece_model.proto.
+const (
+	spmNormal      = 1
+	spmUnknown     = 2
+	spmControl     = 3
+	spmUserDefined = 4
+	spmUnused      = 5
+	spmByte        = 6
+)
+
+// Model types from sentencepiece_model.proto.
+const (
+	spmUnigram = 1
+	spmBPE     = 2
+)
+
+// spmSpace is the meta symbol SentencePiece uses in place of spaces.
+const spmSpace = "▁"
+
+// spmUnkPenalty prices an unknown character in the unigram lattice below
+// the least likely piece, as SentencePiece does.
+const spmUnkPenalty = 10.0
+
+// SentencePieceTokenizer counts tokens with a SentencePiece .model file
+// (Gemini/Gemma, T5, Llama 2 and similar models). Unigram and BPE models
+// are supported.
+type SentencePieceTokenizer struct {
+	path         string
+	description  string
+	modelType    int
+	pieces       map[string]int // NORMAL and USER_DEFINED pieces
+	texts        []string
+	types        []int
+	scores       []float64
+	mergeRank    []int // BPE: rank of each piece, best score first
+	unkID        int
+	byteIDs      [256]int
+	byteFallback bool
+	minScore     float64
+	maxPieceLen  int
+	userDefined  map[string]int
+	maxUserLen   int
+	normalizer   spmNormalizer
+	splitWords   bool
+	wsSuffix     bool
+	wsOnlyPieces bool
+	wordCacheMu  sync.RWMutex
+	wordCache    map[string][]int
+}
+
+// spmNormalizer mirrors NormalizerSpec.
+type spmNormalizer struct {
+	charsmap               *charsmap
+	addDummyPrefix         bool
+	removeExtraWhitespaces bool
+	escapeWhitespaces      bool
+}
+
+// NewSentencePiece loads a SentencePiece model file. The file hash is part
+// of the description so cached counts follow the file.
+func NewSentencePiece(path string) (*SentencePieceTokenizer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tokenizer file: %w", err)
+	}
+	t, err := parseSentencePiece(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse tokenizer file %s: %w", path, err)
+	}
+	t.path = filepath.ToSlash(path)
+	kind := "unigram"
+	if t.modelType == spmBPE {
+		kind = "BPE"
+	}
+	sum := sha256.Sum256(raw)
+	t.description = fmt.Sprintf("%s SentencePiece %s (sha256 %s)", filepath.Base(path), kind, hex.EncodeToString(sum[:6]))
+	return t, nil
+}
+
+func (t *SentencePieceTokenizer) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	normalized := t.normalizer.normalize(text, t.userDefined, t.maxUserLen, t.wsSuffix)
+	words := []string{normalized}
+	if t.splitWords {
+		words = splitSpmWords(normalized, t.wsSuffix, t.wsOnlyPieces)
+	}
+	total := 0
+	for _, word := range words {
+		total += len(t.encodeWord(word))
+	}
+	return total
+}
+
+func (t *SentencePieceTokenizer) Name() string {
+	return "spm"
+}
+
+func (t *SentencePieceTokenizer) Description() string {
+	return t.description
+}
+
+// Label is the list entry that loads the file.
+func (t *SentencePieceTokenizer) Label() string {
+	return "spm:" + t.path
+}
+
+func (t *SentencePieceTokenizer) encodeWord(word string) []int {
+	if word == "" {
+		return nil
+	}
+	t.wordCacheMu.RLock()
+	ids, ok := t.wordCache[word]
+	t.wordCacheMu.RUnlock()
+	if ok {
+		return ids
+	}
+
+	// User-defined pieces are always kept whole; the model only sees the
+	// text between them.
+	pending := 0
+	for i := 0; i < len(word); {
+		if n, id := matchLongest(word[i:], t.userDefined, t.maxUserLen); n > 0 {
+			ids = append(ids, t.encodeText(word[pending:i])...)
+			ids = append(ids, id)
+			i += n
+			pending = i
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(word[i:])
+		i += size
+	}
+	ids = append(ids, t.encodeText(word[pending:])...)
+
+	t.wordCacheMu.Lock()
+	if len(t.wordCache) >= wordCacheLimit {
+		t.wordCache = make(map[string][]int)
+	}
+	t.wordCache[word] = ids
+	t.wordCacheMu.Unlock()
+	return ids
+}
+
+func (t *SentencePieceTokenizer) encodeText(text string) []int {
+	if text == "" {
+		return nil
+	}
+	if t.modelType == spmBPE {
+		return t.encodeBPE(text)
+	}
+	return t.encodeUnigram(text)
+}
+
+// encodeUnigram finds the most likely segmentation with a Viterbi pass over
+// byte positions. Characters no piece covers become unknown tokens.
+func (t *SentencePieceTokenizer) encodeUnigram(text string) []int {
+	n := len(text)
+	best := make([]float64, n+1)
+	from := make([]int, n+1)
+	pieceAt := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(-1)
+	}
+
+	for start := 0; start < n; {
+		_, charLen := utf8.DecodeRuneInString(text[start:])
+		if math.IsInf(best[start], -1) {
+			start += charLen
+			continue
+		}
+		hasSingle := false
+		limit := start + t.maxPieceLen
+		if limit > n {
+			limit = n
+		}
+		for end := start + 1; end <= limit; end++ {
+			id, ok := t.pieces[text[start:end]]
+			if !ok {
+				continue
+			}
+			if score := best[start] + t.scores[id]; score > best[end] {
+				best[end], from[end], pieceAt[end] = score, start, id
+			}
+			if end-start == charLen {
+				hasSingle = true
+			}
+		}
+		if !hasSingle {
+			end := start + charLen
+			if score := best[start] + t.minScore - spmUnkPenalty; score > best[end] {
+				best[end], from[end], pieceAt[end] = score, start, -1
+			}
+		}
+		start += charLen
+	}
+
+	type node struct{ start, end, id int }
+	var path []node
+	for end := n; end > 0; end = from[end] {
+		path = append(path, node{from[end], end, pieceAt[end]})
+	}
+
+	ids := make([]int, 0, len(path))
+	lastUnk := false
+	for i := len(path) - 1; i >= 0; i-- {
+		nd := path[i]
+		if nd.id >= 0 {
+			ids = append(ids, nd.id)
+			lastUnk = false
+			continue
+		}
+		if t.byteFallback {
+			ids = append(ids, t.fallbackBytes(text[nd.start:nd.end])...)
+			continue
+		}
+		// Consecutive unknown characters collapse into one unknown token.
+		if !lastUnk {
+			ids = append(ids, t.unkID)
+		}
+		lastUnk = true
+	}
+	return ids
+}
+
+// encodeBPE merges characters into the highest-scoring pieces.
+func (t *SentencePieceTokenizer) encodeBPE(text string) []int {
+	// Characters missing from the vocab get temporary ids past its end.
+	var extra []string
+	symbol := func(id int) string {
+		if id >= len(t.scores) {
+			return extra[id-len(t.scores)]
+		}
+		return t.texts[id]
+	}
+
+	ids := make([]int, 0, len(text))
+	for _, r := range text {
+		char := string(r)
+		if id, ok := t.pieces[char]; ok {
+			ids = append(ids, id)
+			continue
+		}
+		extra = append(extra, char)
+		ids = append(ids, len(t.scores)+len(extra)-1)
+	}
+
+	ids = mergeSymbols(ids, func(pair [2]int) (bpeMerge, bool) {
+		id, ok := t.pieces[symbol(pair[0])+symbol(pair[1])]
+		if !ok || t.types[id] == spmUserDefined {
+			return bpeMerge{}, false
+		}
+		return bpeMerge{rank: t.mergeRank[id], id: id}, true
+	})
+
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		switch {
+		case id < len(t.scores):
+			out = append(out, id)
+		case t.byteFallback:
+			out = append(out, t.fallbackBytes(symbol(id))...)
+		default:
+			out = append(out, t.unkID)
+		}
+	}
+	return out
+}
+
+// fallbackBytes spells text as <0xXX> byte pieces, or one unknown token
+// when the model lacks them.
+func (t *SentencePieceTokenizer) fallbackBytes(text string) []int {
+	ids := make([]int, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		id := t.byteIDs[text[i]]
+		if id < 0 {
+			return []int{t.unkID}
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// normalize applies the precompiled character map and the whitespace rules
+// of the NormalizerSpec. User-defined pieces are copied unchanged.
+func (n spmNormalizer) normalize(text string, userDefined map[string]int, maxUserLen int, wsSuffix bool) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		if size, _ := matchLongest(text[i:], userDefined, maxUserLen); size > 0 {
+			b.WriteString(text[i : i+size])
+			i += size
+			continue
+		}
+		if n.charsmap != nil {
+			if replacement, size, ok := n.charsmap.longestMatch(text[i:]); ok {
+				b.WriteString(replacement)
+				i += size
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteRune(utf8.RuneError)
+		} else {
+			b.WriteString(text[i : i+size])
+		}
+		i += size
+	}
+	normalized := b.String()
+
+	if n.removeExtraWhitespaces {
+		fields := strings.FieldsFunc(normalized, func(r rune) bool { return r == ' ' })
+		normalized = strings.Join(fields, " ")
+	}
+	if normalized == "" {
+		return ""
+	}
+	if n.addDummyPrefix {
+		if wsSuffix {
+			normalized += " "
+		} else {
+			normalized = " " + normalized
+		}
+	}
+	if n.escapeWhitespaces {
+		normalized = strings.ReplaceAll(normalized, " ", spmSpace)
+	}
+	return normalized
+}
+
+// splitSpmWords splits normalized text at meta spaces the way SentencePiece
+// does before encoding: each word starts (or, with wsSuffix, ends) with one.
+// With wsOnlyPieces a run of meta spaces stays in one word.
+func splitSpmWords(text string, wsSuffix bool, wsOnlyPieces bool) []string {
+	var words []string
+	start := 0
+	inSpaces := false
+	for i := 0; i < len(text); {
+		isSpace := strings.HasPrefix(text[i:], spmSpace)
+		size := len(spmSpace)
+		if !isSpace {
+			_, size = utf8.DecodeRuneInString(text[i:])
+		}
+		if wsSuffix {
+			if isSpace {
+				inSpaces = true
+			} else if inSpaces {
+				if wsOnlyPieces && i > start {
+					words, start = append(words, text[start:i]), i
+				}
+				inSpaces = false
+			}
+			i += size
+			if i < len(text) && isSpace && !wsOnlyPieces {
+				words, start = append(words, text[start:i]), i
+			}
+			continue
+		}
+		if i > 0 && isSpace && (!inSpaces || !wsOnlyPieces) {
+			words, start = append(words, text[start:i]), i
+		}
+		inSpaces = isSpace
+		i += size
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// matchLongest returns the byte length and id of the longest key of set
+// that prefixes text.
+func matchLongest(text string, set map[string]int, maxLen int) (int, int) {
+	if len(set) == 0 {
+		return 0, 0
+	}
+	if maxLen > len(text) {
+		maxLen = len(text)
+	}
+	for n := maxLen; n > 0; n-- {
+		if id, ok := set[text[:n]]; ok {
+			return n, id
+		}
+	}
+	return 0, 0
+}
+
+// parseSentencePiece decodes a serialized ModelProto.
+func parseSentencePiece(raw []byte) (*SentencePieceTokenizer, error) {
+	t := &SentencePieceTokenizer{
+		modelType:   spmUnigram,
+		pieces:      make(map[string]int),
+		unkID:       -1,
+		userDefined: make(map[string]int),
+		splitWords:  true,
+		normalizer: spmNormalizer{
+			addDummyPrefix:         true,
+			removeExtraWhitespaces: true,
+			escapeWhitespaces:      true,
+		},
+		wordCache: make(map[string][]int),
+	}
+	for i := range t.byteIDs {
+		t.byteIDs[i] = -1
+	}
+
+	err := walkProto(raw, func(field int, value protoValue) error {
+		switch field {
+		case 1: // pieces
+			piece, score, pieceType, err := parseSpmPiece(value.bytes)
+			if err != nil {
+				return fmt.Errorf("piece %d: %w", len(t.types), err)
+			}
+			id := len(t.types)
+			t.texts = append(t.texts, piece)
+			t.types = append(t.types, pieceType)
+			t.scores = append(t.scores, score)
+			switch pieceType {
+			case spmNormal:
+				t.pieces[piece] = id
+			case spmUserDefined:
+				t.pieces[piece] = id
+				t.userDefined[piece] = id
+				if len(piece) > t.maxUserLen {
+					t.maxUserLen = len(piece)
+				}
+			case spmUnknown:
+				t.unkID = id
+			case spmByte:
+				if b, ok := byteFallbackValue(piece); ok {
+					t.byteIDs[b] = id
+				}
+			}
+		case 2: // trainer_spec
+			return walkProto(value.bytes, func(field int, value protoValue) error {
+				switch field {
+				case 3:
+					t.modelType = int(value.varint)
+				case 12:
+					t.splitWords = value.varint != 0
+				case 24:
+					t.wsSuffix = value.varint != 0
+				case 26:
+					t.wsOnlyPieces = value.varint != 0
+				case 35:
+					t.byteFallback = value.varint != 0
+				}
+				return nil
+			})
+		case 3: // normalizer_spec
+			return walkProto(value.bytes, func(field int, value protoValue) error {
+				switch field {
+				case 2:
+					if len(value.bytes) == 0 {
+						return nil
+					}
+					cm, err := parseCharsmap(value.bytes)
+					if err != nil {
+						return fmt.Errorf("normalizer: %w", err)
+					}
+					t.normalizer.charsmap = cm
+				case 3:
+					t.normalizer.addDummyPrefix = value.varint != 0
+				case 4:
+					t.normalizer.removeExtraWhitespaces = value.varint != 0
+				case 5:
+					t.normalizer.escapeWhitespaces = value.varint != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.types) == 0 {
+		return nil, fmt.Errorf("model has no pieces")
+	}
+	if t.modelType != spmUnigram && t.modelType != spmBPE {
+		return nil, fmt.Errorf("unsupported model type %d (only unigram and BPE are supported)", t.modelType)
+	}
+	if t.unkID < 0 {
+		return nil, fmt.Errorf("model has no unknown piece")
+	}
+
+	t.minScore = math.Inf(1)
+	for piece, id := range t.pieces {
+		if t.scores[id] < t.minScore {
+			t.minScore = t.scores[id]
+		}
+		if len(piece) > t.maxPieceLen {
+			t.maxPieceLen = len(piece)
+		}
+	}
+	if math.IsInf(t.minScore, 1) {
+		t.minScore = 0
+	}
+
+	if t.modelType == spmBPE {
+		order := make([]int, len(t.scores))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return t.scores[order[i]] > t.scores[order[j]] })
+		t.mergeRank = make([]int, len(t.scores))
+		for rank, id := range order {
+			t.mergeRank[id] = rank
+		}
+	}
+	return t, nil
+}
+
+func parseSpmPiece(raw []byte) (string, float64, int, error) {
+	var (
+		piece     string
+		score     float64
+		pieceType = spmNormal
+	)
+	err := walkProto(raw, func(field int, value protoValue) error {
+		switch field {
+		case 1:
+			piece = string(value.bytes)
+		case 2:
+			score = float64(math.Float32frombits(value.fixed32))
+		case 3:
+			pieceType = int(value.varint)
+		}
+		return nil
+	})
+	return piece, score, pieceType, err
+}
+
+// protoValue holds one decoded protobuf field; which member is set
+// depends on the wire type.
+type protoValue struct {
+	varint  uint64
+	fixed32 uint32
+	bytes   []byte
+}
+
+// walkProto calls fn for each field of a serialized protobuf message. It
+// understands just enough of the wire format to read SentencePiece models.
+func walkProto(buf []byte, fn func(field int, value protoValue) error) error {
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return fmt.Errorf("malformed protobuf key")
+		}
+		buf = buf[n:]
+
+		var value protoValue
+		switch wire := key & 7; wire {
+		case 0:
+			v, n := binary.Uvarint(buf)
+			if n <= 0 {
+				return fmt.Errorf("malformed protobuf varint")
+			}
+			value.varint, buf = v, buf[n:]
+		case 1:
+			if len(buf) < 8 {
+				return fmt.Errorf("truncated protobuf fixed64")
+			}
+			buf = buf[8:]
+		case 2:
+			size, n := binary.Uvarint(buf)
+			if n <= 0 || size > uint64(len(buf)-n) {
+				return fmt.Errorf("truncated protobuf field")
+			}
+			value.bytes, buf = buf[n:n+int(size)], buf[n+int(size):]
+		case 5:
+			if len(buf) < 4 {
+				return fmt.Errorf("truncated protobuf fixed32")
+			}
+			value.fixed32, buf = binary.LittleEndian.Uint32(buf), buf[4:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wire)
+		}
+		if err := fn(int(key>>3), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// charsmap is a precompiled SentencePiece normalization map: a darts-clone
+// double-array trie over UTF-8 input whose values index NUL-terminated
+// replacement strings.
+type charsmap struct {
+	trie       []uint32
+	normalized []byte
+}
+
+func parseCharsmap(blob []byte) (*charsmap, error) {
+	if len(blob) < 4 {
+		return nil, fmt.Errorf("precompiled charsmap too short")
+	}
+	trieSize := binary.LittleEndian.Uint32(blob)
+	if trieSize%4 != 0 || uint64(trieSize) > uint64(len(blob)-4) {
+		return nil, fmt.Errorf("precompiled charsmap has an invalid trie size")
+	}
+	trie := make([]uint32, trieSize/4)
+	for i := range trie {
+		trie[i] = binary.LittleEndian.Uint32(blob[4+4*i:])
+	}
+	if len(trie) == 0 {
+		return nil, fmt.Errorf("precompiled charsmap has an empty trie")
+	}
+	return &charsmap{trie: trie, normalized: blob[4+trieSize:]}, nil
+}
+
+// longestMatch returns the replacement for the longest mapped prefix of
+// text and the number of bytes it replaces.
+func (c *charsmap) longestMatch(text string) (string, int, bool) {
+	node := int(dartsOffset(c.trie[0]))
+	matchLen, matchValue := 0, 0
+	for i := 0; i < len(text); i++ {
+		node ^= int(text[i])
+		if node >= len(c.trie) {
+			break
+		}
+		unit := c.trie[node]
+		if dartsLabel(unit) != uint32(text[i]) {
+			break
+		}
+		node ^= int(dartsOffset(unit))
+		if dartsHasLeaf(unit) && node < len(c.trie) {
+			matchLen, matchValue = i+1, int(dartsValue(c.trie[node]))
+		}
+	}
+	if matchLen == 0 || matchValue >= len(c.normalized) {
+		return "", 0, false
+	}
+	replacement := c.normalized[matchValue:]
+	if end := bytes.IndexByte(replacement, 0); end >= 0 {
+		replacement = replacement[:end]
+	}
+	return string(replacement), matchLen, true
+}
+
+func dartsHasLeaf(unit uint32) bool  { return (unit>>8)&1 == 1 }
+func dartsValue(unit uint32) uint32  { return unit & (1<<31 - 1) }
+func dartsLabel(unit uint32) uint32  { return unit & (1<<31 | 0xFF) }
+func dartsOffset(unit uint32) uint32 { return (unit >> 10) << ((unit & (1 << 9)) >> 6) }
@@ -0,0 +1,70 @@
+package tokenizer
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+)
+
+func TestSentencePiece_Fixtures(t *testing.T) {
+	cases := []struct {
+		fixture string
+		text    string
+		want    int
+	}{
+		{"unigram.model", "hello world", 2},
+		{"unigram.model", "  hello   world ", 2}, // extra whitespace is removed
+		{"unigram.model", "helloxyz", 2},         // unknown characters fuse into one token
+		{"unigram.model", "hello<tool>world", 6}, // user-defined piece, then w+o+r+ld
+		{"unigram.model", "   ", 0},
+		{"bpe.model", "the cat", 2},
+		{"bpe.model", "the✓", 4}, // "✓" falls back to three byte pieces
+		{"bpe.model", "tea", 3},
+	}
+	ref := loadReference(t)
+	for _, tc := range cases {
+		ref.check(t, tc.fixture, tc.text, tc.want)
+		tok, err := New("spm:" + filepath.Join("testdata", tc.fixture))
+		if err != nil {
+			t.Fatalf("load %s: %v", tc.fixture, err)
+		}
+		if got := tok.Count(tc.text); got != tc.want {
+			t.Errorf("%s: Count(%q) = %d, want %d", tc.fixture, tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestSentencePiece_PrecompiledCharsmap(t *testing.T) {
+	// A darts-clone trie holding the single key "Ａ" (fullwidth A) whose
+	// value points at the replacement "A".
+	key := []byte("Ａ")
+	trie := make([]uint32, 1<<(9+len(key)))
+	offset := func(o uint32) uint32 { return o << 10 }
+	trie[0] = offset(1 << 8)
+	node := uint32(1 << 8)
+	for i, c := range key {
+		node ^= uint32(c)
+		next := uint32(1 << (9 + i))
+		trie[node] = offset(next) | uint32(c)
+		if i == len(key)-1 {
+			trie[node] |= 1 << 8 // has leaf
+		}
+		node ^= next
+	}
+	trie[node] = 1<<31 | 0 // leaf: replacement at offset 0
+
+	blob := binary.LittleEndian.AppendUint32(nil, uint32(len(trie)*4))
+	for _, unit := range trie {
+		blob = binary.LittleEndian.AppendUint32(blob, unit)
+	}
+	blob = append(blob, "A\x00"...)
+
+	cm, err := parseCharsmap(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := spmNormalizer{charsmap: cm, addDummyPrefix: true, removeExtraWhitespaces: true, escapeWhitespaces: true}
+	if got := n.normalize("Ａb  Ａ", nil, 0, false); got != "▁Ab▁A" {
+		t.Fatalf("normalize = %q", got)
+	}
+}
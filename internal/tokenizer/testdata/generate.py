@@ -0,0 +1,235 @@
+#!/usr/bin/env python3
+"""Generates the tokenizer fixtures in this directory.
+
+    python3 generate.py              # rewrite the *-style.json and *.model fixtures
+    python3 generate.py --reference  # also write reference.json
+
+The fixtures are small synthetic tokenizers that exercise one feature each
+(ByteLevel, Split regexes, byte fallback, unigram, BPE). Writing them needs
+only the standard library: the .model files are hand-encoded
+sentencepiece_model.proto messages.
+
+--reference loads every fixture with the upstream libraries (pip install
+tokenizers sentencepiece) and records their token counts for REFERENCE_TEXTS
+in reference.json, which TestReferenceCounts compares against.
+"""
+
+import json
+import os
+import struct
+import sys
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+
+# Texts counted with the upstream libraries for reference.json. Every text
+# in TestHuggingFace_Fixtures and TestSentencePiece_Fixtures must be here.
+REFERENCE_TEXTS = [
+    "the cat sat",
+    "the cat",
+    "the mat",
+    "a<|endoftext|>b",
+    "<|begin_of_text|>the",
+    "<s>hello",
+    "héllo",
+    "  x",
+    "12345",
+    "hello world",
+    "  hello   world ",
+    "hi✓",
+    "helloxyz",
+    "hello<tool>world",
+    "the✓",
+    "tea",
+    "   ",
+    "",
+]
+
+
+def write_text(name, obj):
+    with open(os.path.join(HERE, name), "w", encoding="utf-8") as f:
+        f.write(json.dumps(obj, ensure_ascii=False, indent=2) + "\n")
+
+
+# --- Hugging Face tokenizer.json fixtures ---------------------------------
+
+
+def bytes_to_unicode():
+    bs = list(range(ord("!"), ord("~") + 1)) + list(range(0xA1, 0xAD)) + list(range(0xAE, 0x100))
+    cs = bs[:]
+    n = 0
+    for b in range(256):
+        if b not in bs:
+            bs.append(b)
+            cs.append(256 + n)
+            n += 1
+    return {b: chr(c) for b, c in zip(bs, cs)}
+
+
+BYTE_LEVEL = bytes_to_unicode()
+BYTE_VOCAB = {BYTE_LEVEL[b]: b for b in range(256)}
+BYTE_MERGES = [("h", "e"), ("Ġ", "t"), ("Ġt", "he"), ("a", "t"), ("Ġ", "c"), ("Ġc", "at"),
+               ("Ġ", "s"), ("Ġs", "at"), ("t", "he"), ("Ã", "©")]
+LLAMA3_PATTERN = (r"(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}"
+                  r"| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+")
+
+
+def byte_vocab(extra=()):
+    vocab = dict(BYTE_VOCAB)
+    for a, b in BYTE_MERGES:
+        vocab.setdefault(a + b, len(vocab))
+    for token in extra:
+        vocab.setdefault(token, len(vocab))
+    return vocab
+
+
+def write_hf_fixtures():
+    vocab = byte_vocab()
+    write_text("gpt2-style.json", {
+        "version": "1.0",
+        "added_tokens": [{"id": len(vocab), "content": "<|endoftext|>", "special": True}],
+        "normalizer": None,
+        "pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": False, "trim_offsets": True, "use_regex": True},
+        "post_processor": None,
+        "decoder": {"type": "ByteLevel", "add_prefix_space": True, "trim_offsets": True, "use_regex": True},
+        "model": {"type": "BPE", "dropout": None, "unk_token": None, "continuing_subword_prefix": "",
+                  "end_of_word_suffix": "", "fuse_unk": False, "byte_fallback": False,
+                  "vocab": vocab, "merges": [a + " " + b for a, b in BYTE_MERGES]},
+    })
+
+    vocab = byte_vocab(["Ġmat"])
+    write_text("llama3-style.json", {
+        "version": "1.0",
+        "added_tokens": [{"id": len(vocab), "content": "<|begin_of_text|>", "special": True}],
+        "normalizer": None,
+        "pre_tokenizer": {"type": "Sequence", "pretokenizers": [
+            {"type": "Split", "pattern": {"Regex": LLAMA3_PATTERN}, "behavior": "Isolated", "invert": False},
+            {"type": "ByteLevel", "add_prefix_space": False, "trim_offsets": True, "use_regex": False}]},
+        "post_processor": None,
+        "decoder": {"type": "ByteLevel", "add_prefix_space": True, "trim_offsets": True, "use_regex": True},
+        "model": {"type": "BPE", "dropout": None, "unk_token": None, "continuing_subword_prefix": None,
+                  "end_of_word_suffix": None, "fuse_unk": False, "byte_fallback": False, "ignore_merges": True,
+                  "vocab": vocab, "merges": [[a, b] for a, b in BYTE_MERGES]},
+    })
+
+    vocab = {"<unk>": 0, "<s>": 1, "</s>": 2}
+    for b in range(256):
+        vocab["<0x%02X>" % b] = len(vocab)
+    for c in ["▁", "h", "e", "l", "o", "w", "r", "d"]:
+        vocab[c] = len(vocab)
+    merges = [("l", "l"), ("e", "ll"), ("h", "ell"), ("hell", "o"), ("▁", "hello"), ("▁", "w"),
+              ("o", "r"), ("▁w", "or"), ("l", "d"), ("▁wor", "ld")]
+    for a, b in merges:
+        vocab.setdefault(a + b, len(vocab))
+    write_text("mistral-style.json", {
+        "version": "1.0",
+        "added_tokens": [{"id": 0, "content": "<unk>", "special": True},
+                         {"id": 1, "content": "<s>", "special": True},
+                         {"id": 2, "content": "</s>", "special": True}],
+        "normalizer": {"type": "Sequence", "normalizers": [
+            {"type": "Prepend", "prepend": "▁"},
+            {"type": "Replace", "pattern": {"String": " "}, "content": "▁"}]},
+        "pre_tokenizer": None,
+        "post_processor": None,
+        "decoder": {"type": "Sequence", "decoders": [
+            {"type": "Replace", "pattern": {"String": "▁"}, "content": " "},
+            {"type": "ByteFallback"}, {"type": "Fuse"},
+            {"type": "Strip", "content": " ", "start": 1, "stop": 0}]},
+        "model": {"type": "BPE", "dropout": None, "unk_token": "<unk>", "continuing_subword_prefix": None,
+                  "end_of_word_suffix": None, "fuse_unk": True, "byte_fallback": True,
+                  "vocab": vocab, "merges": [a + " " + b for a, b in merges]},
+    })
+
+
+# --- SentencePiece .model fixtures ----------------------------------------
+# Field numbers follow sentencepiece_model.proto: ModelProto.pieces = 1,
+# trainer_spec = 2, normalizer_spec = 3; SentencePiece.piece = 1,
+# score = 2, type = 3 (1 NORMAL, 2 UNKNOWN, 3 CONTROL, 4 USER_DEFINED,
+# 6 BYTE); TrainerSpec.model_type = 3 (1 UNIGRAM, 2 BPE),
+# byte_fallback = 35; NormalizerSpec.name = 1, add_dummy_prefix = 3,
+# remove_extra_whitespaces = 4, escape_whitespaces = 5.
+
+
+def varint(n):
+    out = b""
+    while True:
+        b = n & 0x7F
+        n >>= 7
+        if n:
+            out += bytes([b | 0x80])
+        else:
+            return out + bytes([b])
+
+
+def field_key(field, wire):
+    return varint(field << 3 | wire)
+
+
+def length_delimited(field, data):
+    return field_key(field, 2) + varint(len(data)) + data
+
+
+def varint_field(field, n):
+    return field_key(field, 0) + varint(n)
+
+
+def float_field(field, x):
+    return field_key(field, 5) + struct.pack("<f", x)
+
+
+def piece(text, score, kind=1):
+    return length_delimited(1, length_delimited(1, text.encode()) + float_field(2, score) + varint_field(3, kind))
+
+
+def model(pieces, trainer, normalizer):
+    return b"".join(pieces) + length_delimited(2, trainer) + length_delimited(3, normalizer)
+
+
+IDENTITY_NORMALIZER = length_delimited(1, b"identity") + varint_field(3, 1) + varint_field(4, 1) + varint_field(5, 1)
+
+
+def write_spm_fixtures():
+    pieces = [piece("<unk>", 0, 2), piece("<s>", 0, 3), piece("</s>", 0, 3),
+              piece("▁", -2.0), piece("▁hello", -3.0), piece("▁world", -3.5), piece("▁he", -2.5),
+              piece("llo", -2.5), piece("▁wor", -3.0), piece("ld", -3.0)]
+    for c in "helowrd":
+        pieces.append(piece(c, -4.0))
+    pieces.append(piece("<tool>", 0, 4))
+    with open(os.path.join(HERE, "unigram.model"), "wb") as f:
+        f.write(model(pieces, varint_field(3, 1), IDENTITY_NORMALIZER))
+
+    pieces = [piece("<unk>", 0, 2), piece("<s>", 0, 3), piece("</s>", 0, 3)]
+    for b in range(256):
+        pieces.append(piece("<0x%02X>" % b, 0, 6))
+    for i, text in enumerate(["▁t", "he", "▁the", "▁c", "at", "▁cat"]):
+        pieces.append(piece(text, -(i + 1)))
+    for i, c in enumerate(["▁", "t", "h", "e", "c", "a"]):
+        pieces.append(piece(c, -(10 + i)))
+    with open(os.path.join(HERE, "bpe.model"), "wb") as f:
+        f.write(model(pieces, varint_field(3, 2) + varint_field(35, 1), IDENTITY_NORMALIZER))
+
+
+# --- Reference counts -----------------------------------------------------
+
+
+def write_reference():
+    import sentencepiece
+    import tokenizers
+
+    counts = {}
+    for name in ["gpt2-style.json", "llama3-style.json", "mistral-style.json"]:
+        tok = tokenizers.Tokenizer.from_file(os.path.join(HERE, name))
+        counts[name] = {text: len(tok.encode(text, add_special_tokens=False).ids) for text in REFERENCE_TEXTS}
+    for name in ["unigram.model", "bpe.model"]:
+        sp = sentencepiece.SentencePieceProcessor(model_file=os.path.join(HERE, name))
+        counts[name] = {text: len(sp.encode(text)) for text in REFERENCE_TEXTS}
+    write_text("reference.json", {
+        "generated_by": "tokenizers %s, sentencepiece %s" % (tokenizers.__version__, sentencepiece.__version__),
+        "counts": counts,
+    })
+
+
+if __name__ == "__main__":
+    write_hf_fixtures()
+    write_spm_fixtures()
+    if "--reference" in sys.argv[1:]:
+        write_reference()
@@ -1,11 +1,55 @@
 package tokenizer
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 
 	tiktoken "github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
 )
 
+func init() {
+	// Serve BPE ranks from the copies embedded in the binary so tiktoken
+	// never downloads them at runtime.
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// encodingSpec is the split pattern and special tokens of an encoding,
+// needed to rebuild it around ranks loaded from a local file.
+type encodingSpec struct {
+	pattern       string
+	specialTokens map[string]int
+}
+
+var encodingSpecs = map[string]encodingSpec{
+	"cl100k_base": {
+		pattern: `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`,
+		specialTokens: map[string]int{
+			tiktoken.ENDOFTEXT:   100257,
+			tiktoken.FIM_PREFIX:  100258,
+			tiktoken.FIM_MIDDLE:  100259,
+			tiktoken.FIM_SUFFIX:  100260,
+			tiktoken.ENDOFPROMPT: 100276,
+		},
+	},
+	"o200k_base": {
+		pattern: `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+			`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+			`|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+`,
+		specialTokens: map[string]int{
+			tiktoken.ENDOFTEXT:   199999,
+			tiktoken.ENDOFPROMPT: 200018,
+		},
+	},
+}
+
 // TiktokenTokenizer uses a concrete tiktoken encoding.
 type TiktokenTokenizer struct {
 	name        string
@@ -26,6 +70,81 @@ func NewTiktoken(name string, encoding string, description string) (*TiktokenTok
 	}, nil
 }
 
+// NewTiktokenFromFile creates a tokenizer for encoding whose BPE ranks are
+// read from a local .tiktoken file instead of the embedded copy. The file
+// hash is part of the description so cached counts follow the file.
+func NewTiktokenFromFile(name string, encoding string, rankFile string) (*TiktokenTokenizer, error) {
+	spec, ok := encodingSpecs[encoding]
+	if !ok {
+		return nil, fmt.Errorf("init tokenizer %s: no split pattern known for encoding %s", name, encoding)
+	}
+
+	raw, err := os.ReadFile(rankFile)
+	if err != nil {
+		return nil, fmt.Errorf("read tokenizer file: %w", err)
+	}
+	ranks, err := parseTiktokenRanks(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse tokenizer file %s: %w", rankFile, err)
+	}
+
+	bpe, err := tiktoken.NewCoreBPE(ranks, spec.specialTokens, spec.pattern)
+	if err != nil {
+		return nil, fmt.Errorf("init tokenizer %s (%s): %w", name, encoding, err)
+	}
+	specialSet := make(map[string]any, len(spec.specialTokens))
+	for token := range spec.specialTokens {
+		specialSet[token] = true
+	}
+	enc := &tiktoken.Encoding{
+		Name:           encoding,
+		PatStr:         spec.pattern,
+		MergeableRanks: ranks,
+		SpecialTokens:  spec.specialTokens,
+	}
+
+	sum := sha256.Sum256(raw)
+	return &TiktokenTokenizer{
+		name:        name,
+		description: fmt.Sprintf("%s from %s (sha256 %s)", encoding, filepath.Base(rankFile), hex.EncodeToString(sum[:6])),
+		encoder:     tiktoken.NewTiktoken(bpe, enc, specialSet),
+	}, nil
+}
+
+// parseTiktokenRanks reads "<base64 token> <rank>" lines.
+func parseTiktokenRanks(raw []byte) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		fields := bytes.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<token> <rank>\"", lineNo)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("no ranks found")
+	}
+	return ranks, nil
+}
+
 func (t *TiktokenTokenizer) Count(text string) int {
 	if text == "" {
 		return 0
@@ -0,0 +1,57 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTiktoken_EmbeddedEncodingsWorkOffline(t *testing.T) {
+	for _, name := range []string{"openai", "openai-o200k"} {
+		tok, err := New(name)
+		if err != nil {
+			t.Fatalf("New(%s): %v", name, err)
+		}
+		if got := tok.Count("hello world"); got != 2 {
+			t.Fatalf("%s: expected 2 tokens for %q, got %d", name, "hello world", got)
+		}
+	}
+}
+
+func TestTiktoken_RankFile(t *testing.T) {
+	var b strings.Builder
+	rank := 0
+	for i := 0; i < 256; i++ {
+		b.WriteString(fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), rank))
+		rank++
+	}
+	for _, merge := range []string{"he", "ll", "hell", "hello"} {
+		b.WriteString(fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte(merge)), rank))
+		rank++
+	}
+	path := filepath.Join(t.TempDir(), "tiny.tiktoken")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := NewWithOptions("openai", Options{RankFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "hello" merges to one token; " world" has no merges beyond bytes.
+	if got := tok.Count("hello world"); got != 7 {
+		t.Fatalf("expected 7 tokens, got %d", got)
+	}
+	if !strings.Contains(tok.Description(), "tiny.tiktoken") {
+		t.Fatalf("expected description to name the rank file, got %q", tok.Description())
+	}
+
+	// The estimate takes ratio files through RatioFile, never RankFile, so
+	// the rank file is refused before it is read.
+	if _, err := NewWithOptions("estimate", Options{RankFile: path}); err == nil || !strings.Contains(err.Error(), "requires a tiktoken tokenizer") {
+		t.Fatalf("expected a rank file to be refused for the estimate tokenizer, got %v", err)
+	}
+}
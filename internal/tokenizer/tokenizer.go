@@ -12,20 +12,161 @@ type Tokenizer interface {
 	Description() string
 }
 
+// PathCounter is implemented by tokenizers whose count depends on the file
+// the text came from, such as the per-extension estimate.
+type PathCounter interface {
+	CountPath(path string, text string) int
+}
+
+// labeler is implemented by tokenizers loaded from a file, whose Name is
+// shared by every file of their kind.
+type labeler interface {
+	Label() string
+}
+
+// Label tells tokenizers apart in a comparison: the Name, or for file
+// tokenizers the list entry that loaded them, such as "hf:llama.json".
+func Label(tok Tokenizer) string {
+	if l, ok := tok.(labeler); ok {
+		return l.Label()
+	}
+	return tok.Name()
+}
+
+// CountFile counts text read from path, using CountPath when tok has it.
+func CountFile(tok Tokenizer, path string, text string) int {
+	if pc, ok := tok.(PathCounter); ok {
+		return pc.CountPath(path, text)
+	}
+	return tok.Count(text)
+}
+
+// Options tunes tokenizer construction.
+type Options struct {
+	// RankFile replaces the embedded BPE ranks of a tiktoken encoding with
+	// a local .tiktoken file.
+	RankFile string
+	// RatioFile is a chars/token table written by tokcount calibrate for
+	// the estimate tokenizer.
+	RatioFile string
+	// CharsPerToken is the estimate ratio for extensions missing from the
+	// table; <= 0 uses DefaultCharsPerToken.
+	CharsPerToken float64
+	// EstimateRatios override the estimate's built-in chars/token table,
+	// keyed by extension (".go") or multi-part suffix (".min.js").
+	EstimateRatios map[string]float64
+}
+
 // New creates a tokenizer by name.
 func New(name string) (Tokenizer, error) {
+	return NewWithOptions(name, Options{})
+}
+
+// NewWithOptions creates a tokenizer by name with construction options.
+// "hf:<path>" loads a Hugging Face tokenizer.json file and "spm:<path>" a
+// SentencePiece model.
+func NewWithOptions(name string, opts Options) (Tokenizer, error) {
+	hfPath, isHF := filePath(name, "hf:")
+	spmPath, isSPM := filePath(name, "spm:")
+	if (isHF || isSPM) && strings.TrimSpace(opts.RankFile) != "" {
+		return nil, fmt.Errorf("a tokenizer file requires a tiktoken tokenizer (openai, openai-o200k, anthropic)")
+	}
+	switch {
+	case isHF:
+		return NewHuggingFace(hfPath)
+	case isSPM:
+		return NewSentencePiece(spmPath)
+	}
+
+	var (
+		tokName     string
+		encoding    string
+		description string
+	)
 	switch normalize(name) {
 	case "", "estimate", "google", "gemini":
-		return NewEstimate(3.5), nil
+		if strings.TrimSpace(opts.RankFile) != "" {
+			return nil, fmt.Errorf("a tokenizer file requires a tiktoken tokenizer (openai, openai-o200k, anthropic); the estimate loads a ratio file instead")
+		}
+		return newEstimate(opts)
 	case "anthropic", "claude":
-		return NewTiktoken("anthropic", "cl100k_base", "cl100k_base (Claude approximation)")
+		tokName, encoding, description = "anthropic", "cl100k_base", "cl100k_base (Claude approximation)"
 	case "openai":
-		return NewTiktoken("openai", "cl100k_base", "cl100k_base (GPT-4)")
+		tokName, encoding, description = "openai", "cl100k_base", "cl100k_base (GPT-4)"
 	case "openai-o200k":
-		return NewTiktoken("openai-o200k", "o200k_base", "o200k_base (GPT-4o/o1)")
+		tokName, encoding, description = "openai-o200k", "o200k_base", "o200k_base (GPT-4o/o1)"
 	default:
 		return nil, fmt.Errorf("unsupported tokenizer: %s", name)
 	}
+
+	if strings.TrimSpace(opts.RankFile) != "" {
+		return NewTiktokenFromFile(tokName, encoding, opts.RankFile)
+	}
+	return NewTiktoken(tokName, encoding, description)
+}
+
+// NewList creates every tokenizer of a comma-separated list such as
+// "openai,openai-o200k,estimate", in order. Labels must be distinct, and a
+// RankFile is only accepted for a single tokenizer.
+func NewList(names string, opts Options) ([]Tokenizer, error) {
+	parts := strings.Split(names, ",")
+	if len(parts) > 1 && strings.TrimSpace(opts.RankFile) != "" {
+		return nil, fmt.Errorf("a tokenizer file applies to a single tokenizer, not %q", names)
+	}
+	toks := make([]Tokenizer, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		if len(parts) > 1 && strings.TrimSpace(part) == "" {
+			return nil, fmt.Errorf("empty entry in tokenizer list %q", names)
+		}
+		tok, err := NewWithOptions(part, opts)
+		if err != nil {
+			return nil, err
+		}
+		label := Label(tok)
+		if seen[label] {
+			return nil, fmt.Errorf("tokenizer %s is listed more than once", label)
+		}
+		seen[label] = true
+		toks = append(toks, tok)
+	}
+	return toks, nil
+}
+
+// newEstimate layers a ratio file, then EstimateRatios, over the built-in
+// estimate table.
+func newEstimate(opts Options) (*EstimateTokenizer, error) {
+	ratioFile := strings.TrimSpace(opts.RatioFile)
+	if ratioFile == "" {
+		return NewCalibratedEstimate(opts.CharsPerToken, opts.EstimateRatios)
+	}
+
+	file, err := LoadRatioFile(ratioFile)
+	if err != nil {
+		return nil, err
+	}
+	ratios := make(map[string]float64, len(file.Ratios)+len(opts.EstimateRatios))
+	for ext, ratio := range file.Ratios {
+		ratios[ext] = ratio
+	}
+	for ext, ratio := range opts.EstimateRatios {
+		ratios[ext] = ratio
+	}
+	charsPerToken := opts.CharsPerToken
+	if charsPerToken <= 0 {
+		charsPerToken = file.CharsPerToken
+	}
+	return NewCalibratedEstimate(charsPerToken, ratios)
+}
+
+// filePath returns the path of a "<prefix><path>" tokenizer name. The path
+// keeps its case; only the prefix is matched case-insensitively.
+func filePath(name string, prefix string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if len(name) <= len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+		return "", false
+	}
+	return name[len(prefix):], true
 }
 
 func normalize(name string) string {